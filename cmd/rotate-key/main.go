@@ -0,0 +1,69 @@
+// rotate-key 使用新密钥重新加密已保存的凭据
+//
+// 旧密钥取自配置文件或 ENCRYPTION_KEY 环境变量，新密钥取自 -new-key 参数或 NEW_ENCRYPTION_KEY 环境变量。
+// 执行前应停止服务，完成后把配置中的密钥替换为新密钥再启动。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/secret"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage/sqlite"
+)
+
+func main() {
+	newKey := flag.String("new-key", os.Getenv("NEW_ENCRYPTION_KEY"), "new encryption key (defaults to $NEW_ENCRYPTION_KEY)")
+	flag.Parse()
+
+	if err := run(*newKey); err != nil {
+		fmt.Fprintf(os.Stderr, "key rotation failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(newKey string) error {
+	configPath := os.Getenv("CONFIG_PATH")
+	if configPath == "" {
+		configPath = "configs/config.yaml"
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// 旧密钥为空时只加密旧版本保存的明文凭据
+	var oldCipher *secret.Cipher
+	if cfg.Security.EncryptionKey != "" {
+		if oldCipher, err = secret.NewCipher(cfg.Security.EncryptionKey); err != nil {
+			return fmt.Errorf("invalid current key: %w", err)
+		}
+	}
+
+	newCipher, err := secret.NewCipher(newKey)
+	if err != nil {
+		return fmt.Errorf("invalid new key: %w", err)
+	}
+
+	store, err := sqlite.NewSQLiteStore(cfg.Storage.SQLite.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.Init(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	n, err := secret.Rotate(context.Background(), store.Credentials(), oldCipher, newCipher)
+	if err != nil {
+		return fmt.Errorf("rotated %d credentials before error: %w", n, err)
+	}
+
+	fmt.Printf("re-encrypted %d credentials, update security.encryption_key (or ENCRYPTION_KEY) to the new key before restarting\n", n)
+	return nil
+}
@@ -16,9 +16,14 @@ import (
 	"github.com/hanxuanyu/gitcodestatic/internal/git"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/scheduler"
+	"github.com/hanxuanyu/gitcodestatic/internal/secret"
 	"github.com/hanxuanyu/gitcodestatic/internal/service"
 	"github.com/hanxuanyu/gitcodestatic/internal/stats"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage/sqlite"
+	"github.com/hanxuanyu/gitcodestatic/internal/teams"
+	"github.com/hanxuanyu/gitcodestatic/internal/webhook"
 	"github.com/hanxuanyu/gitcodestatic/internal/worker"
 )
 
@@ -44,60 +49,133 @@ func main() {
 	}
 
 	// 初始化存储
-	store, err := sqlite.NewSQLiteStore(cfg.Storage.SQLite.Path)
+	sqliteStore, err := sqlite.NewSQLiteStore(cfg.Storage.SQLite.Path)
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msg("failed to create store")
 	}
-	defer store.Close()
+	defer sqliteStore.Close()
 
-	if err := store.Init(); err != nil {
+	if err := sqliteStore.Init(); err != nil {
 		logger.Logger.Fatal().Err(err).Msg("failed to initialize database")
 	}
 
 	logger.Logger.Info().Msg("database initialized")
 
-	// 创建Git管理器
-	gitManager := git.NewCmdGitManager(cfg.Git.CommandPath)
-	if !gitManager.IsAvailable() {
-		logger.Logger.Warn().Msg("git command not available, some features may not work")
-	} else {
-		logger.Logger.Info().Msg("git command available")
+	// 凭据加密
+	credCipher, err := newCredentialCipher(cfg, sqliteStore)
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("failed to set up credential encryption")
+	}
+	store := secret.NewStore(sqliteStore, credCipher)
+
+	// 创建Git管理器和统计计算器
+	gitManager, calculator, err := newGitBackend(cfg)
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("failed to create git backend")
+	}
+
+	// 迁移旧布局下的克隆目录
+	if migrated, failed, err := service.MigrateWorkspaceLayout(context.Background(), store, cfg.Workspace.CacheDir); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("failed to migrate workspace layout")
+	} else if migrated > 0 || failed > 0 {
+		logger.Logger.Info().Int("migrated", migrated).Int("failed", failed).Msg("workspace layout migrated")
 	}
 
-	// 创建统计计算器
-	calculator := stats.NewCalculator(cfg.Git.CommandPath)
+	// 旧版本的完整工作副本转换为裸镜像
+	if n, err := service.ConvertWorkingCopies(context.Background(), store); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("failed to convert working copies")
+	} else if n > 0 {
+		logger.Logger.Info().Int("count", n).Msg("working copies converted to bare mirrors")
+	}
+
+	// 旧版本的凭据按所绑定仓库的主机绑定
+	if n, err := service.BindCredentialHosts(context.Background(), store); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("failed to bind credential hosts")
+	} else if n > 0 {
+		logger.Logger.Info().Int("count", n).Msg("credentials bound to hosts")
+	}
 
 	// 创建缓存
 	fileCache := cache.NewFileCache(store, cfg.Workspace.StatsDir)
 
+	// 创建团队注册表
+	teamRegistry := teams.NewRegistry(store, cfg.Teams)
+	if _, err := teamRegistry.Mapper(context.Background()); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("invalid team configuration")
+	}
+
 	// 创建任务队列
 	queue := worker.NewQueue(cfg.Worker.QueueBuffer, store)
 
+	// 任务事件分发，供 /tasks/stream 推送
+	broadcaster := worker.NewBroadcaster()
+	queue.AddListener(broadcaster)
+
 	// 创建任务处理器
 	handlers := map[string]worker.TaskHandler{
-		models.TaskTypeClone:  worker.NewCloneHandler(store, gitManager),
-		models.TaskTypePull:   worker.NewPullHandler(store, gitManager),
+		models.TaskTypeClone:  worker.NewCloneHandler(store, gitManager, cfg.Git.CloneTimeout),
+		models.TaskTypePull:   worker.NewPullHandler(store, gitManager, cfg.Git.PullTimeout),
 		models.TaskTypeSwitch: worker.NewSwitchHandler(store, gitManager),
-		models.TaskTypeReset:  worker.NewResetHandler(store, gitManager, fileCache),
-		models.TaskTypeStats:  worker.NewStatsHandler(store, calculator, fileCache, gitManager),
+		models.TaskTypeReset:  worker.NewResetHandler(store, gitManager, fileCache, cfg.Workspace.CacheDir, cfg.Git.CloneTimeout),
+		models.TaskTypeStats:  worker.NewStatsHandler(store, calculator, fileCache, gitManager, teamRegistry),
+	}
+
+	// 启动事件投递，需在worker池之前注册以免遗漏任务事件
+	var dispatcher *webhook.Dispatcher
+	if cfg.Webhooks.Outbound.Enabled {
+		dispatcher = webhook.NewDispatcher(store, cfg.Webhooks.Outbound)
+		queue.AddListener(dispatcher)
+		dispatcher.Start()
+		defer dispatcher.Stop()
 	}
 
 	// 创建Worker池
 	totalWorkers := cfg.Worker.CloneWorkers + cfg.Worker.PullWorkers +
 		cfg.Worker.StatsWorkers + cfg.Worker.GeneralWorkers
 
-	pool := worker.NewPool(totalWorkers, queue, store, handlers)
+	pool := worker.NewPool(totalWorkers, queue, store, handlers, cfg.Worker.Retry)
 	pool.Start()
 	defer pool.Stop()
 
 	logger.Logger.Info().Int("workers", totalWorkers).Msg("worker pool started")
 
+	// 恢复上次运行遗留的pending/running任务和克隆中断的仓库
+	if err := pool.Recover(context.Background()); err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to recover tasks")
+	}
+
+	// 启动仓库自动同步
+	if cfg.Scheduler.Sync.Enabled {
+		syncScheduler := scheduler.NewSyncScheduler(store, queue, cfg.Scheduler.Sync, cfg.Scheduler.CheckInterval)
+		syncScheduler.Start()
+		defer syncScheduler.Stop()
+	}
+
 	// 创建服务层
-	repoService := service.NewRepoService(store, queue, cfg.Workspace.CacheDir, gitManager)
-	statsService := service.NewStatsService(store, queue, fileCache, gitManager)
+	repoService := service.NewRepoService(store, queue, cfg.Workspace.CacheDir, cfg.Security.LocalRepoRoots, gitManager)
+	statsService := service.NewStatsService(store, queue, fileCache, gitManager, teamRegistry)
+	aggregateService := service.NewAggregateService(store, queue, fileCache, gitManager, teamRegistry, statsService)
+	teamService := service.NewTeamService(store, teamRegistry)
+	credentialService := service.NewCredentialService(store, gitManager)
+	commitService := service.NewCommitService(store, gitManager, calculator)
+	reportService := service.NewReportService(store, queue, aggregateService, cfg.Scheduler.Reports.PullIfOlderThan)
+	hookService := service.NewHookService(store, queue, statsService, cfg.Webhooks.Inbound)
+	webhookService := service.NewWebhookService(store, dispatcher)
+	taskService := service.NewTaskService(store, queue)
+
+	// 后台运行需在worker池停止前结束，defer按逆序执行
+	defer reportService.Stop()
+	defer hookService.Stop()
+
+	// 启动定时报表
+	if cfg.Scheduler.Reports.Enabled {
+		reportScheduler := scheduler.NewReportScheduler(reportService, cfg.Scheduler.CheckInterval)
+		reportScheduler.Start()
+		defer reportScheduler.Stop()
+	}
 
 	// 设置路由
-	router := api.NewRouter(repoService, statsService, store, cfg.Web.Dir, cfg.Web.Enabled)
+	router := api.NewRouter(repoService, statsService, aggregateService, teamService, credentialService, commitService, reportService, hookService, webhookService, taskService, broadcaster, store, cfg.Web.Dir, cfg.Web.Enabled)
 	handler := router.Setup()
 
 	// 创建HTTP服务器
@@ -108,6 +186,8 @@ func main() {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
+	// 关闭时结束SSE连接，否则Shutdown会一直等待这些请求返回
+	srv.RegisterOnShutdown(broadcaster.Close)
 
 	// 启动服务器
 	go func() {
@@ -151,6 +231,66 @@ func loadConfig() (*config.Config, error) {
 	return config.LoadConfig(configPath)
 }
 
+// newCredentialCipher 创建凭据加密器
+//
+// 未配置密钥时只要已有凭据就拒绝启动，没有凭据时允许启动但不能创建凭据。
+func newCredentialCipher(cfg *config.Config, store storage.Store) (*secret.Cipher, error) {
+	count, unencrypted, err := secret.Count(context.Background(), store.Credentials())
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Security.EncryptionKey != "" {
+		c, err := secret.NewCipher(cfg.Security.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		if unencrypted > 0 {
+			n, err := secret.MigrateLegacy(context.Background(), store.Credentials(), c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt legacy credentials: %w", err)
+			}
+			logger.Logger.Info().Int("count", n).Msg("legacy plaintext credentials encrypted")
+		}
+		return c, nil
+	}
+
+	if count > 0 {
+		return nil, fmt.Errorf("%d stored credentials found but no encryption key configured, set security.encryption_key or ENCRYPTION_KEY", count)
+	}
+
+	logger.Logger.Warn().Msg("no encryption key configured, credentials cannot be stored")
+	return nil, nil
+}
+
+// newGitBackend 根据配置选择git命令或go-git实现
+func newGitBackend(cfg *config.Config) (git.Manager, *stats.Calculator, error) {
+	cmdManager := git.NewCmdGitManager(cfg.Git.CommandPath)
+
+	switch cfg.Git.Backend {
+	case config.GitBackendCmd:
+		if !cmdManager.IsAvailable() {
+			logger.Logger.Warn().Msg("git command not available, some features may not work")
+		}
+	case config.GitBackendGoGit:
+		logger.Logger.Info().Msg("using go-git backend")
+		return git.NewGoGitManager(), stats.NewCalculatorWithReader(stats.NewGoGitLogReader()), nil
+	case config.GitBackendAuto:
+		if cmdManager.IsAvailable() {
+			logger.Logger.Info().Msg("git command available")
+		} else if *cfg.Git.FallbackToGoGit {
+			logger.Logger.Warn().Msg("git command not available, falling back to go-git backend")
+			return git.NewGoGitManager(), stats.NewCalculatorWithReader(stats.NewGoGitLogReader()), nil
+		} else {
+			logger.Logger.Warn().Msg("git command not available, some features may not work")
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown git backend: %s", cfg.Git.Backend)
+	}
+
+	return cmdManager, stats.NewCalculator(cfg.Git.CommandPath), nil
+}
+
 // ensureDirectories 确保工作目录存在
 func ensureDirectories(cfg *config.Config) error {
 	dirs := []string{
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+)
+
+// CommitHandler 提交浏览API处理器
+type CommitHandler struct {
+	commitService *service.CommitService
+}
+
+// NewCommitHandler 创建提交浏览处理器
+func NewCommitHandler(commitService *service.CommitService) *CommitHandler {
+	return &CommitHandler{
+		commitService: commitService,
+	}
+}
+
+// List 分页获取提交列表
+// @Summary 获取提交列表
+// @Description 按引用、作者、路径、时间范围和提交说明过滤提交，每条提交包含numstat汇总及文件列表；默认与统计一致排除合并提交，使用 next_cursor 翻页
+// @Tags 提交浏览
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Param ref query string false "分支、标签或commit，默认仓库当前分支"
+// @Param author query string false "作者名或邮箱（不区分大小写的子串匹配）"
+// @Param path query string false "文件或目录路径"
+// @Param since query string false "起始日期(YYYY-MM-DD或RFC3339)"
+// @Param until query string false "截止日期(YYYY-MM-DD或RFC3339)，纯日期包含当天"
+// @Param message query string false "提交说明（不区分大小写的子串匹配）"
+// @Param include_merges query bool false "是否包含合并提交" default(false)
+// @Param limit query int false "每页数量，最大100" default(20)
+// @Param cursor query string false "上一页返回的next_cursor"
+// @Success 200 {object} Response{data=service.ListCommitsResponse}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /repos/{id}/commits [get]
+func (h *CommitHandler) List(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	query := r.URL.Query()
+	req := &service.ListCommitsRequest{
+		RepoID:  id,
+		Ref:     query.Get("ref"),
+		Author:  query.Get("author"),
+		Path:    query.Get("path"),
+		Since:   query.Get("since"),
+		Until:   query.Get("until"),
+		Message: query.Get("message"),
+		Cursor:  query.Get("cursor"),
+	}
+	if v := query.Get("include_merges"); v != "" {
+		if req.IncludeMerges, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, 40001, "invalid include_merges")
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if req.Limit, err = strconv.Atoi(v); err != nil {
+			respondError(w, http.StatusBadRequest, 40001, "invalid limit")
+			return
+		}
+	}
+
+	resp, err := h.commitService.ListCommits(r.Context(), req)
+	if err != nil {
+		respondCommitError(w, err, id, "failed to list commits")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
+// Get 获取提交详情
+// @Summary 获取提交详情
+// @Description 获取提交的作者、提交人、完整说明、trailer、父提交及逐文件numstat；diff=true时附带相对第一个父提交的unified diff，按文件分页，每页字节数超过上限的文件被截断
+// @Tags 提交浏览
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Param sha path string true "commit hash（至少4位）"
+// @Param diff query bool false "是否返回diff" default(false)
+// @Param diff_offset query int false "diff起始文件序号" default(0)
+// @Param diff_limit query int false "每页文件数，最大500" default(50)
+// @Param max_diff_bytes query int false "每页patch字节数上限，最大4194304" default(524288)
+// @Success 200 {object} Response{data=models.CommitDetail}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /repos/{id}/commits/{sha} [get]
+func (h *CommitHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	query := r.URL.Query()
+	req := &service.GetCommitRequest{
+		RepoID: id,
+		SHA:    chi.URLParam(r, "sha"),
+	}
+	if v := query.Get("diff"); v != "" {
+		if req.WithDiff, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, 40001, "invalid diff")
+			return
+		}
+	}
+	for name, dst := range map[string]*int{
+		"diff_offset":    &req.DiffOffset,
+		"diff_limit":     &req.DiffLimit,
+		"max_diff_bytes": &req.DiffMaxBytes,
+	} {
+		if v := query.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				respondError(w, http.StatusBadRequest, 40001, "invalid "+name)
+				return
+			}
+		}
+	}
+
+	detail, err := h.commitService.GetCommit(r.Context(), req)
+	if err != nil {
+		respondCommitError(w, err, id, "failed to get commit")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", detail)
+}
+
+// respondCommitError 参数错误返回400，仓库、引用或提交不存在返回404，仓库未就绪返回409，其余返回500
+func respondCommitError(w http.ResponseWriter, err error, repoID int64, msg string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCommitQuery):
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+	case errors.Is(err, service.ErrRepoNotFound),
+		errors.Is(err, service.ErrCommitNotFound),
+		errors.Is(err, git.ErrRefNotFound):
+		respondError(w, http.StatusNotFound, 40400, err.Error())
+	case errors.Is(err, service.ErrRepoNotReady):
+		respondError(w, http.StatusConflict, 40900, err.Error())
+	default:
+		logger.Logger.Error().Err(err).Int64("repo_id", repoID).Msg(msg)
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+	}
+}
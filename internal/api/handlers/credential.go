@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+)
+
+// CredentialHandler 凭据API处理器
+type CredentialHandler struct {
+	credentialService *service.CredentialService
+}
+
+// NewCredentialHandler 创建凭据处理器
+func NewCredentialHandler(credentialService *service.CredentialService) *CredentialHandler {
+	return &CredentialHandler{
+		credentialService: credentialService,
+	}
+}
+
+// List 获取凭据列表
+// @Summary 获取凭据列表
+// @Description 获取所有凭据及使用它们的仓库，不返回密码、令牌和私钥
+// @Tags 凭据管理
+// @Produce json
+// @Success 200 {object} Response{data=[]service.CredentialInfo}
+// @Failure 500 {object} Response
+// @Router /credentials [get]
+func (h *CredentialHandler) List(w http.ResponseWriter, r *http.Request) {
+	creds, err := h.credentialService.ListCredentials(r.Context())
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list credentials")
+		respondError(w, http.StatusInternalServerError, 50000, "failed to list credentials")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", creds)
+}
+
+// Get 获取凭据详情
+// @Summary 获取凭据详情
+// @Description 获取凭据元信息，不返回密码、令牌和私钥
+// @Tags 凭据管理
+// @Produce json
+// @Param id path string true "凭据ID"
+// @Success 200 {object} Response{data=service.CredentialInfo}
+// @Failure 404 {object} Response
+// @Router /credentials/{id} [get]
+func (h *CredentialHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	cred, err := h.credentialService.GetCredential(r.Context(), id)
+	if err != nil {
+		respondCredentialError(w, err, id, "failed to get credential")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", cred)
+}
+
+// Create 创建凭据
+// @Summary 创建凭据
+// @Description 创建basic/token/ssh凭据并绑定主机，可绑定到该主机上的多个仓库
+// @Tags 凭据管理
+// @Accept json
+// @Produce json
+// @Param request body service.CredentialRequest true "凭据"
+// @Success 200 {object} Response{data=service.CredentialInfo}
+// @Failure 400 {object} Response
+// @Router /credentials [post]
+func (h *CredentialHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req service.CredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	cred, err := h.credentialService.CreateCredential(r.Context(), &req)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to create credential")
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "credential created", cred)
+}
+
+// Update 更新凭据
+// @Summary 更新凭据
+// @Description 更新凭据名称或轮换密码/令牌/私钥，留空的敏感字段保持不变，修改主机时必须同时提交新的密钥；使用该凭据的仓库下次拉取时生效
+// @Tags 凭据管理
+// @Accept json
+// @Produce json
+// @Param id path string true "凭据ID"
+// @Param request body service.CredentialRequest true "凭据"
+// @Success 200 {object} Response{data=service.CredentialInfo}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /credentials/{id} [put]
+func (h *CredentialHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req service.CredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	cred, err := h.credentialService.UpdateCredential(r.Context(), id, &req)
+	if err != nil {
+		respondCredentialError(w, err, id, "failed to update credential")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "credential updated", cred)
+}
+
+// Delete 删除凭据
+// @Summary 删除凭据
+// @Description 删除未被任何仓库使用的凭据
+// @Tags 凭据管理
+// @Produce json
+// @Param id path string true "凭据ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /credentials/{id} [delete]
+func (h *CredentialHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.credentialService.DeleteCredential(r.Context(), id); err != nil {
+		respondCredentialError(w, err, id, "failed to delete credential")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "credential deleted successfully", nil)
+}
+
+// Test 测试凭据
+// @Summary 测试凭据
+// @Description 使用凭据对仓库URL执行 git ls-remote，验证能否访问，仓库必须位于凭据绑定的主机上，不支持本地仓库
+// @Tags 凭据管理
+// @Accept json
+// @Produce json
+// @Param id path string true "凭据ID"
+// @Param request body service.TestCredentialRequest true "仓库URL或仓库ID"
+// @Success 200 {object} Response{data=service.TestCredentialResponse}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /credentials/{id}/test [post]
+func (h *CredentialHandler) Test(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req service.TestCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	resp, err := h.credentialService.TestCredential(r.Context(), id, &req)
+	if err != nil {
+		respondCredentialError(w, err, id, "failed to test credential")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
+// respondCredentialError 凭据不存在返回404，其余返回400
+func respondCredentialError(w http.ResponseWriter, err error, id, msg string) {
+	if errors.Is(err, service.ErrCredentialNotFound) {
+		respondError(w, http.StatusNotFound, 40400, err.Error())
+		return
+	}
+
+	logger.Logger.Error().Err(err).Str("credential_id", id).Msg(msg)
+	respondError(w, http.StatusBadRequest, 40001, err.Error())
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+	"github.com/hanxuanyu/gitcodestatic/internal/webhook"
+)
+
+// HookHandler 接收代码托管平台webhook的处理器
+type HookHandler struct {
+	hookService *service.HookService
+}
+
+// NewHookHandler 创建webhook处理器
+func NewHookHandler(hookService *service.HookService) *HookHandler {
+	return &HookHandler{
+		hookService: hookService,
+	}
+}
+
+// Receive 接收push事件
+// @Summary 接收push webhook
+// @Description 接收GitHub/GitLab/Gitea的push事件：校验签名或令牌后按仓库地址匹配已添加的仓库并提交pull任务，推送仓库当前分支时拉取完成后按配置重新计算统计。非push事件（如ping）直接返回成功
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param provider path string true "平台" Enums(github, gitlab, gitea)
+// @Success 200 {object} Response{data=service.HookResult}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /hooks/{provider} [post]
+func (h *HookHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.hookService.MaxPayloadSize()))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, 40001, "payload too large or unreadable")
+		return
+	}
+
+	event, err := h.hookService.Receive(provider, r.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrUnknownProvider):
+			respondError(w, http.StatusNotFound, 40400, err.Error())
+		case errors.Is(err, webhook.ErrProviderDisabled):
+			respondError(w, http.StatusForbidden, 40300, err.Error())
+		case errors.Is(err, webhook.ErrInvalidSignature):
+			logger.Logger.Warn().Str("provider", provider).Str("remote_addr", r.RemoteAddr).Msg("webhook signature verification failed")
+			respondError(w, http.StatusUnauthorized, 40100, err.Error())
+		case errors.Is(err, webhook.ErrIgnoredEvent):
+			respondJSON(w, http.StatusOK, 0, err.Error(), nil)
+		default:
+			respondError(w, http.StatusBadRequest, 40001, err.Error())
+		}
+		return
+	}
+
+	result, err := h.hookService.HandlePush(r.Context(), event)
+	if err != nil {
+		logger.Logger.Error().Err(err).Str("provider", provider).Str("ref", event.Ref).Msg("failed to handle push webhook")
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", result)
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/secret"
 	"github.com/hanxuanyu/gitcodestatic/internal/service"
 )
 
@@ -44,7 +47,22 @@ func (h *RepoHandler) AddBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.SSHPrivateKey != "" {
+		if err := service.ValidateSSHKey(req.SSHPrivateKey, req.SSHPassphrase); err != nil {
+			respondError(w, http.StatusBadRequest, 40001, err.Error())
+			return
+		}
+	}
+
 	resp, err := h.repoService.AddRepos(r.Context(), &req)
+	if errors.Is(err, service.ErrCredentialNotFound) {
+		respondError(w, http.StatusNotFound, 40400, err.Error())
+		return
+	}
+	if errors.Is(err, secret.ErrNoEncryptionKey) {
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("failed to add repositories")
 		respondError(w, http.StatusInternalServerError, 50000, "failed to add repositories")
@@ -270,3 +288,200 @@ func (h *RepoHandler) GetBranches(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, 0, "success", data)
 }
+
+// ListRefs 获取仓库分支和标签
+// @Summary 获取仓库分支和标签
+// @Description 获取本地分支、远程分支和标签，包含指向的提交、提交时间和作者，按提交时间从新到旧排序，并附带相对默认分支的领先/落后提交数。
+// @Description 出于性能考虑只为最近的100个引用计算领先/落后，超出时 ahead_behind_truncated 为 true
+// @Tags 仓库管理
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Param type query string false "引用类型(branch/remote/tag)，为空返回全部"
+// @Param ahead_behind query bool false "是否计算领先/落后提交数" default(true)
+// @Success 200 {object} Response{data=service.ListRefsResponse}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /repos/{id}/refs [get]
+func (h *RepoHandler) ListRefs(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	withAheadBehind := true
+	if v := r.URL.Query().Get("ahead_behind"); v != "" {
+		if withAheadBehind, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, 40001, "invalid ahead_behind")
+			return
+		}
+	}
+
+	resp, err := h.repoService.ListRefs(r.Context(), id, r.URL.Query().Get("type"), withAheadBehind)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefQuery):
+			respondError(w, http.StatusBadRequest, 40001, err.Error())
+		case errors.Is(err, service.ErrRepoNotFound):
+			respondError(w, http.StatusNotFound, 40400, err.Error())
+		case errors.Is(err, service.ErrRepoNotReady):
+			respondError(w, http.StatusConflict, 40900, err.Error())
+		default:
+			logger.Logger.Error().Err(err).Int64("repo_id", id).Msg("failed to list refs")
+			respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
+// SetLabels 设置仓库标签
+// @Summary 设置仓库标签
+// @Description 覆盖设置仓库标签，标签可用于跨仓库聚合统计
+// @Tags 仓库管理
+// @Accept json
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Param request body object{labels=[]string} true "标签列表"
+// @Success 200 {object} Response{data=models.Repository}
+// @Failure 400 {object} Response
+// @Router /repos/{id}/labels [put]
+func (h *RepoHandler) SetLabels(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	var req struct {
+		Labels []string `json:"labels"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	repo, err := h.repoService.SetLabels(r.Context(), id, req.Labels)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("repo_id", id).Msg("failed to set repository labels")
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", repo)
+}
+
+// SetSync 设置仓库自动同步间隔
+// @Summary 设置仓库自动同步间隔
+// @Description 覆盖全局自动同步间隔（分钟），0关闭该仓库的自动同步，null恢复使用全局配置；上次/下次同步时间见仓库的 last_sync_at/next_sync_at
+// @Tags 仓库管理
+// @Accept json
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Param request body object{interval_minutes=int} true "同步间隔"
+// @Success 200 {object} Response{data=models.Repository}
+// @Failure 400 {object} Response
+// @Router /repos/{id}/sync [put]
+func (h *RepoHandler) SetSync(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	var req struct {
+		IntervalMinutes *int `json:"interval_minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+	if req.IntervalMinutes != nil && *req.IntervalMinutes < 0 {
+		respondError(w, http.StatusBadRequest, 40001, "interval_minutes must be non-negative")
+		return
+	}
+
+	repo, err := h.repoService.SetSyncInterval(r.Context(), id, req.IntervalMinutes)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("repo_id", id).Msg("failed to set repository sync interval")
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", repo)
+}
+
+// SetCredential 绑定仓库凭据
+// @Summary 绑定仓库凭据
+// @Description 为仓库绑定已有凭据，下次克隆/拉取时生效
+// @Tags 仓库管理
+// @Accept json
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Param request body object{credential_id=string} true "凭据ID"
+// @Success 200 {object} Response{data=models.Repository}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /repos/{id}/credential [put]
+func (h *RepoHandler) SetCredential(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	var req struct {
+		CredentialID string `json:"credential_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+	if req.CredentialID == "" {
+		respondError(w, http.StatusBadRequest, 40001, "credential_id is required")
+		return
+	}
+
+	h.setCredential(w, r, id, req.CredentialID)
+}
+
+// RemoveCredential 解除仓库凭据
+// @Summary 解除仓库凭据
+// @Description 解除仓库绑定的凭据，凭据本身不会被删除
+// @Tags 仓库管理
+// @Produce json
+// @Param id path int true "仓库ID"
+// @Success 200 {object} Response{data=models.Repository}
+// @Failure 400 {object} Response
+// @Router /repos/{id}/credential [delete]
+func (h *RepoHandler) RemoveCredential(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid repository id")
+		return
+	}
+
+	h.setCredential(w, r, id, "")
+}
+
+// setCredential 更新仓库凭据绑定
+func (h *RepoHandler) setCredential(w http.ResponseWriter, r *http.Request, id int64, credentialID string) {
+	repo, err := h.repoService.SetCredential(r.Context(), id, credentialID)
+	if errors.Is(err, service.ErrCredentialNotFound) {
+		respondError(w, http.StatusNotFound, 40400, err.Error())
+		return
+	}
+	if errors.Is(err, git.ErrCredentialHostMismatch) {
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("repo_id", id).Msg("failed to set repository credential")
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", repo)
+}
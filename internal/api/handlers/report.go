@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+)
+
+// defaultReportRunsLimit 默认返回的运行记录数
+const defaultReportRunsLimit = 20
+
+// ReportHandler 定时报表API处理器
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+// NewReportHandler 创建定时报表处理器
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// List 获取报表列表
+// @Summary 获取报表列表
+// @Description 获取所有定时报表定义及上次/下次运行时间
+// @Tags 定时报表
+// @Produce json
+// @Success 200 {object} Response{data=[]models.Report}
+// @Failure 500 {object} Response
+// @Router /reports [get]
+func (h *ReportHandler) List(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.reportService.ListReports(r.Context())
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list reports")
+		respondError(w, http.StatusInternalServerError, 50000, "failed to list reports")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", reports)
+}
+
+// Get 获取报表详情
+// @Summary 获取报表详情
+// @Description 获取定时报表定义
+// @Tags 定时报表
+// @Produce json
+// @Param id path int true "报表ID"
+// @Success 200 {object} Response{data=models.Report}
+// @Failure 404 {object} Response
+// @Router /reports/{id} [get]
+func (h *ReportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.GetReport(r.Context(), id)
+	if err != nil {
+		respondReportError(w, err, id, "failed to get report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", report)
+}
+
+// Create 创建报表
+// @Summary 创建报表
+// @Description 创建定时报表：仓库ID列表或标签、分支、统计约束（或相对时间范围如previous_month，运行时解析）及cron表达式
+// @Tags 定时报表
+// @Accept json
+// @Produce json
+// @Param request body service.ReportRequest true "报表定义"
+// @Success 200 {object} Response{data=models.Report}
+// @Failure 400 {object} Response
+// @Router /reports [post]
+func (h *ReportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req service.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	report, err := h.reportService.CreateReport(r.Context(), &req)
+	if err != nil {
+		respondReportError(w, err, 0, "failed to create report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", report)
+}
+
+// Update 更新报表
+// @Summary 更新报表
+// @Description 覆盖更新定时报表定义，修改cron后重新计算下次运行时间
+// @Tags 定时报表
+// @Accept json
+// @Produce json
+// @Param id path int true "报表ID"
+// @Param request body service.ReportRequest true "报表定义"
+// @Success 200 {object} Response{data=models.Report}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /reports/{id} [put]
+func (h *ReportHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	report, err := h.reportService.UpdateReport(r.Context(), id, &req)
+	if err != nil {
+		respondReportError(w, err, id, "failed to update report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", report)
+}
+
+// Delete 删除报表
+// @Summary 删除报表
+// @Description 删除定时报表及其运行记录，已生成的统计缓存不受影响
+// @Tags 定时报表
+// @Produce json
+// @Param id path int true "报表ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /reports/{id} [delete]
+func (h *ReportHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.reportService.DeleteReport(r.Context(), id); err != nil {
+		respondReportError(w, err, id, "failed to delete report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "report deleted", nil)
+}
+
+// Run 立即运行报表
+// @Summary 立即运行报表
+// @Description 立即运行一次报表，不影响定时计划；通过运行记录查看结果
+// @Tags 定时报表
+// @Produce json
+// @Param id path int true "报表ID"
+// @Success 200 {object} Response{data=models.ReportRun}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /reports/{id}/run [post]
+func (h *ReportHandler) Run(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	run, err := h.reportService.RunReport(r.Context(), id)
+	if err != nil {
+		respondReportError(w, err, id, "failed to run report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "report run started", run)
+}
+
+// ListRuns 获取报表运行记录
+// @Summary 获取报表运行记录
+// @Description 按开始时间从新到旧返回运行记录，包括运行时解析出的统计约束、聚合键及各仓库的统计缓存键
+// @Tags 定时报表
+// @Produce json
+// @Param id path int true "报表ID"
+// @Param limit query int false "返回数量" default(20)
+// @Success 200 {object} Response{data=[]models.ReportRun}
+// @Failure 404 {object} Response
+// @Router /reports/{id}/runs [get]
+func (h *ReportHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultReportRunsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, 40001, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.reportService.ListRuns(r.Context(), id, limit)
+	if err != nil {
+		respondReportError(w, err, id, "failed to list report runs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", runs)
+}
+
+// parseReportID 解析路径中的报表ID
+func parseReportID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid report id")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondReportError 报表不存在返回404，其余返回400
+func respondReportError(w http.ResponseWriter, err error, id int64, msg string) {
+	if errors.Is(err, service.ErrReportNotFound) {
+		respondError(w, http.StatusNotFound, 40400, err.Error())
+		return
+	}
+
+	logger.Logger.Error().Err(err).Int64("report_id", id).Msg(msg)
+	respondError(w, http.StatusBadRequest, 40001, err.Error())
+}
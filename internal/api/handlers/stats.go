@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/service"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
@@ -12,15 +14,17 @@ import (
 
 // StatsHandler 统计API处理器
 type StatsHandler struct {
-	statsService *service.StatsService
-	store        storage.Store
+	statsService     *service.StatsService
+	aggregateService *service.AggregateService
+	store            storage.Store
 }
 
 // NewStatsHandler 创建统计处理器
-func NewStatsHandler(statsService *service.StatsService, store storage.Store) *StatsHandler {
+func NewStatsHandler(statsService *service.StatsService, aggregateService *service.AggregateService, store storage.Store) *StatsHandler {
 	return &StatsHandler{
-		statsService: statsService,
-		store:        store,
+		statsService:     statsService,
+		aggregateService: aggregateService,
+		store:            store,
 	}
 }
 
@@ -122,6 +126,96 @@ func (h *StatsHandler) QueryResult(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, 0, "success", result)
 }
 
+// Compare 周期对比
+// @Summary 周期对比
+// @Description 对比同一仓库分支两个约束（如本月与上月）的统计结果。缺失的一侧会提交统计任务，任务完成后再次请求即可
+// @Tags 统计管理
+// @Accept json
+// @Produce json
+// @Param request body service.CompareRequest true "对比请求"
+// @Success 200 {object} Response{data=service.CompareResponse}
+// @Failure 400 {object} Response
+// @Router /stats/compare [post]
+func (h *StatsHandler) Compare(w http.ResponseWriter, r *http.Request) {
+	var req service.CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	if req.RepoID == 0 {
+		respondError(w, http.StatusBadRequest, 40001, "repo_id is required")
+		return
+	}
+
+	if req.Branch == "" {
+		respondError(w, http.StatusBadRequest, 40001, "branch is required")
+		return
+	}
+
+	resp, err := h.statsService.Compare(r.Context(), &req)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to compare stats")
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
+// Aggregate 跨仓库聚合统计
+// @Summary 跨仓库聚合统计
+// @Description 按仓库ID列表或标签对多个仓库分别统计，并按身份合并贡献者。结果未就绪时返回聚合键和任务状态
+// @Tags 统计管理
+// @Accept json
+// @Produce json
+// @Param request body service.AggregateRequest true "聚合请求"
+// @Success 200 {object} Response{data=service.AggregateResponse}
+// @Failure 400 {object} Response
+// @Router /stats/aggregate [post]
+func (h *StatsHandler) Aggregate(w http.ResponseWriter, r *http.Request) {
+	var req service.AggregateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	resp, err := h.aggregateService.Submit(r.Context(), &req)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to submit aggregate stats")
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
+// GetAggregate 查询跨仓库聚合结果
+// @Summary 查询跨仓库聚合结果
+// @Description 根据聚合键查询聚合统计结果或进度
+// @Tags 统计管理
+// @Produce json
+// @Param key path string true "聚合键"
+// @Success 200 {object} Response{data=service.AggregateResponse}
+// @Failure 404 {object} Response
+// @Router /stats/aggregate/{key} [get]
+func (h *StatsHandler) GetAggregate(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+
+	resp, err := h.aggregateService.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, service.ErrAggregateNotFound) {
+			respondError(w, http.StatusNotFound, 40400, err.Error())
+			return
+		}
+		logger.Logger.Error().Err(err).Str("aggregate_key", key).Msg("failed to get aggregate stats")
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
 // CountCommits 统计提交次数
 // @Summary 统计提交次数
 // @Description 统计指定条件下的提交次数
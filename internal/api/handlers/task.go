@@ -1,28 +1,43 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
 )
 
+// streamHeartbeat SSE心跳间隔，保持连接不被代理断开
+const streamHeartbeat = 15 * time.Second
+
 // TaskHandler 任务API处理器
 type TaskHandler struct {
-	store storage.Store
+	store       storage.Store
+	taskService *service.TaskService
+	broadcaster *worker.Broadcaster
 }
 
 // NewTaskHandler 创建任务处理器
-func NewTaskHandler(store storage.Store) *TaskHandler {
+func NewTaskHandler(store storage.Store, taskService *service.TaskService, broadcaster *worker.Broadcaster) *TaskHandler {
 	return &TaskHandler{
-		store: store,
+		store:       store,
+		taskService: taskService,
+		broadcaster: broadcaster,
 	}
 }
 
 // List 查询任务列表
 // @Summary 查询任务列表
-// @Description 查询任务列表，可按状态过滤
+// @Description 查询任务列表，可按状态过滤。运行中的任务在 progress 中返回当前阶段和百分比
 // @Tags 任务管理
 // @Produce json
 // @Param status query string false "任务状态"
@@ -60,6 +75,41 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, 0, "success", data)
 }
 
+// Cancel 取消任务
+// @Summary 取消任务
+// @Description 待处理的任务从队列移除并标记为 cancelled；运行中的任务终止git进程，处理器退出后标记为 cancelled，返回的任务此时仍为 running。被取消的克隆会删除不完整的目录并将仓库标记为失败
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} Response{data=models.Task}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /tasks/{id}/cancel [post]
+func (h *TaskHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid task id")
+		return
+	}
+
+	task, err := h.taskService.CancelTask(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, worker.ErrTaskNotFound):
+			respondError(w, http.StatusNotFound, 40400, err.Error())
+		case errors.Is(err, worker.ErrTaskFinished):
+			respondError(w, http.StatusConflict, 40900, err.Error())
+		default:
+			logger.Logger.Error().Err(err).Int64("task_id", id).Msg("failed to cancel task")
+			respondError(w, http.StatusInternalServerError, 50000, "failed to cancel task")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "task cancelled", task)
+}
+
 // ClearAllTasks 清除所有任务记录
 // @Summary 清除所有任务记录
 // @Description 删除所有任务记录（包括进行中的）
@@ -97,3 +147,173 @@ func (h *TaskHandler) ClearCompletedTasks(w http.ResponseWriter, r *http.Request
 	logger.Logger.Info().Msg("completed tasks cleared")
 	respondJSON(w, http.StatusOK, 0, "已完成的任务记录已清除", nil)
 }
+
+// Stream 订阅所有任务的实时事件
+// @Summary 订阅任务事件流
+// @Description Server-Sent Events：任务新建及状态变化推送 event: status，运行中的进度更新推送 event: progress，data 为任务的JSON快照。每15秒发送一次注释行作为心跳，服务关闭时结束连接
+// @Tags 任务管理
+// @Produce text/event-stream
+// @Param repo_id query int false "只推送该仓库的任务"
+// @Success 200 {object} worker.TaskEvent
+// @Failure 400 {object} Response
+// @Router /tasks/stream [get]
+func (h *TaskHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var repoID int64
+	if v := r.URL.Query().Get("repo_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, 40001, "invalid repo_id")
+			return
+		}
+		repoID = id
+	}
+
+	events, unsubscribe := h.broadcaster.Subscribe(0)
+	defer unsubscribe()
+
+	rc, ok := startEventStream(w)
+	if !ok {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.broadcaster.Done():
+			return
+		case <-heartbeat.C:
+			if !writeHeartbeat(w, rc) {
+				return
+			}
+		case event := <-events:
+			if repoID != 0 && event.Task.RepoID != repoID {
+				continue
+			}
+			if !writeTaskEvent(w, rc, event) {
+				return
+			}
+		}
+	}
+}
+
+// StreamTask 订阅单个任务的实时事件
+// @Summary 订阅单个任务事件流
+// @Description Server-Sent Events：连接后先推送任务当前状态，之后推送状态变化和进度，任务结束（完成、失败或取消）或服务关闭时结束连接
+// @Tags 任务管理
+// @Produce text/event-stream
+// @Param id path int true "任务ID"
+// @Success 200 {object} worker.TaskEvent
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /tasks/{id}/stream [get]
+func (h *TaskHandler) StreamTask(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid task id")
+		return
+	}
+
+	// 先订阅再读取当前状态，避免丢失两者之间的事件
+	events, unsubscribe := h.broadcaster.Subscribe(id)
+	defer unsubscribe()
+
+	task, err := h.store.Tasks().GetByID(r.Context(), id)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("task_id", id).Msg("failed to get task")
+		respondError(w, http.StatusInternalServerError, 50000, "failed to get task")
+		return
+	}
+	if task == nil {
+		respondError(w, http.StatusNotFound, 40400, "task not found")
+		return
+	}
+
+	rc, ok := startEventStream(w)
+	if !ok {
+		return
+	}
+
+	if !writeTaskEvent(w, rc, worker.TaskEvent{Type: worker.TaskEventStatus, Task: task}) || isTaskFinished(task) {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.broadcaster.Done():
+			return
+		case <-heartbeat.C:
+			if !writeHeartbeat(w, rc) {
+				return
+			}
+			// 订阅者过慢时事件可能被丢弃，心跳时确认任务是否已经结束
+			if task, err := h.store.Tasks().GetByID(r.Context(), id); err == nil && task != nil && isTaskFinished(task) {
+				writeTaskEvent(w, rc, worker.TaskEvent{Type: worker.TaskEventStatus, Task: task})
+				return
+			}
+		case event := <-events:
+			if !writeTaskEvent(w, rc, event) || isTaskFinished(event.Task) {
+				return
+			}
+		}
+	}
+}
+
+// startEventStream 写入SSE响应头，并取消服务器写超时以保持长连接
+func startEventStream(w http.ResponseWriter) (*http.ResponseController, bool) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Logger.Warn().Err(err).Msg("failed to clear write deadline for event stream")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		logger.Logger.Error().Err(err).Msg("streaming not supported")
+		return nil, false
+	}
+	return rc, true
+}
+
+// writeTaskEvent 写入一条SSE事件，返回false表示连接已断开
+func writeTaskEvent(w http.ResponseWriter, rc *http.ResponseController, event worker.TaskEvent) bool {
+	data, err := json.Marshal(event.Task)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("task_id", event.Task.ID).Msg("failed to marshal task event")
+		return true
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return false
+	}
+	return rc.Flush() == nil
+}
+
+// writeHeartbeat 写入SSE注释行作为心跳
+func writeHeartbeat(w http.ResponseWriter, rc *http.ResponseController) bool {
+	if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+		return false
+	}
+	return rc.Flush() == nil
+}
+
+// isTaskFinished 任务是否已结束
+func isTaskFinished(task *models.Task) bool {
+	switch task.Status {
+	case models.TaskStatusCompleted, models.TaskStatusFailed, models.TaskStatusCancelled:
+		return true
+	}
+	return false
+}
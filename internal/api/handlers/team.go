@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+)
+
+// TeamHandler 团队API处理器
+type TeamHandler struct {
+	teamService *service.TeamService
+}
+
+// NewTeamHandler 创建团队处理器
+func NewTeamHandler(teamService *service.TeamService) *TeamHandler {
+	return &TeamHandler{
+		teamService: teamService,
+	}
+}
+
+// List 获取团队列表
+// @Summary 获取团队列表
+// @Description 获取配置文件和API定义的所有团队及当前映射版本
+// @Tags 团队管理
+// @Produce json
+// @Success 200 {object} Response{data=service.ListTeamsResponse}
+// @Failure 500 {object} Response
+// @Router /teams [get]
+func (h *TeamHandler) List(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.teamService.ListTeams(r.Context())
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list teams")
+		respondError(w, http.StatusInternalServerError, 50000, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", resp)
+}
+
+// Create 创建团队
+// @Summary 创建团队
+// @Description 创建团队，成员通过邮箱或邮箱正则匹配
+// @Tags 团队管理
+// @Accept json
+// @Produce json
+// @Param request body service.TeamRequest true "团队定义"
+// @Success 200 {object} Response{data=models.Team}
+// @Failure 400 {object} Response
+// @Router /teams [post]
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req service.TeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	team, err := h.teamService.CreateTeam(r.Context(), &req)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to create team")
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "team created", team)
+}
+
+// Update 更新团队
+// @Summary 更新团队
+// @Description 更新API定义的团队（配置文件中的团队只读）
+// @Tags 团队管理
+// @Accept json
+// @Produce json
+// @Param id path int true "团队ID"
+// @Param request body service.TeamRequest true "团队定义"
+// @Success 200 {object} Response{data=models.Team}
+// @Failure 400 {object} Response
+// @Router /teams/{id} [put]
+func (h *TeamHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid team id")
+		return
+	}
+
+	var req service.TeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	team, err := h.teamService.UpdateTeam(r.Context(), id, &req)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("team_id", id).Msg("failed to update team")
+		respondError(w, http.StatusBadRequest, 40001, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "team updated", team)
+}
+
+// Delete 删除团队
+// @Summary 删除团队
+// @Description 删除API定义的团队
+// @Tags 团队管理
+// @Produce json
+// @Param id path int true "团队ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /teams/{id} [delete]
+func (h *TeamHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid team id")
+		return
+	}
+
+	if err := h.teamService.DeleteTeam(r.Context(), id); err != nil {
+		logger.Logger.Error().Err(err).Int64("team_id", id).Msg("failed to delete team")
+		respondError(w, http.StatusInternalServerError, 50000, "failed to delete team")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "team deleted successfully", nil)
+}
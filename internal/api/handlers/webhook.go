@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+)
+
+// WebhookHandler 事件订阅API处理器
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler 创建事件订阅处理器
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// List 获取订阅列表
+// @Summary 获取事件订阅列表
+// @Description 获取所有事件订阅（不含签名密钥）
+// @Tags 事件订阅
+// @Produce json
+// @Success 200 {object} Response{data=[]models.WebhookSubscription}
+// @Failure 500 {object} Response
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhookService.ListWebhooks(r.Context())
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list webhooks")
+		respondError(w, http.StatusInternalServerError, 50000, "failed to list webhooks")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", subs)
+}
+
+// Get 获取订阅详情
+// @Summary 获取事件订阅详情
+// @Description 获取事件订阅（不含签名密钥）
+// @Tags 事件订阅
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response{data=models.WebhookSubscription}
+// @Failure 404 {object} Response
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.webhookService.GetWebhook(r.Context(), id)
+	if err != nil {
+		respondWebhookError(w, err, id, "failed to get webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", sub)
+}
+
+// Create 创建订阅
+// @Summary 创建事件订阅
+// @Description 订阅 task.completed、task.failed、repo.ready、repo.failed、stats.ready 事件（* 表示全部）。投递请求头 X-GitCodeStatic-Signature-256 为 "sha256=" 加请求体的HMAC-SHA256，密钥为空时自动生成，只在创建时返回
+// @Tags 事件订阅
+// @Accept json
+// @Produce json
+// @Param request body service.WebhookRequest true "订阅定义"
+// @Success 200 {object} Response{data=service.CreateWebhookResponse}
+// @Failure 400 {object} Response
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req service.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	sub, err := h.webhookService.CreateWebhook(r.Context(), &req)
+	if err != nil {
+		respondWebhookError(w, err, 0, "failed to create webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", sub)
+}
+
+// Update 更新订阅
+// @Summary 更新事件订阅
+// @Description 覆盖更新地址、事件和备注，secret非空时轮换签名密钥
+// @Tags 事件订阅
+// @Accept json
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Param request body service.WebhookRequest true "订阅定义"
+// @Success 200 {object} Response{data=models.WebhookSubscription}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid request body")
+		return
+	}
+
+	sub, err := h.webhookService.UpdateWebhook(r.Context(), id, &req)
+	if err != nil {
+		respondWebhookError(w, err, id, "failed to update webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", sub)
+}
+
+// Delete 删除订阅
+// @Summary 删除事件订阅
+// @Description 删除事件订阅及其投递记录，未完成的投递不再重试
+// @Tags 事件订阅
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), id); err != nil {
+		respondWebhookError(w, err, id, "failed to delete webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "webhook deleted", nil)
+}
+
+// ListDeliveries 获取投递记录
+// @Summary 获取投递记录
+// @Description 按创建时间从新到旧返回投递记录，包括请求体、投递次数、最近一次响应状态码和失败原因
+// @Tags 事件订阅
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Param status query string false "投递状态" Enums(pending, success, failed)
+// @Param limit query int false "返回数量" default(50)
+// @Success 200 {object} Response{data=[]models.WebhookDelivery}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, 40001, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), id, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		respondWebhookError(w, err, id, "failed to list webhook deliveries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", deliveries)
+}
+
+// Ping 测试投递
+// @Summary 测试投递
+// @Description 立即向订阅地址投递一次 ping 事件并返回投递记录，失败时按重试策略继续投递
+// @Tags 事件订阅
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Success 200 {object} Response{data=models.WebhookDelivery}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /webhooks/{id}/ping [post]
+func (h *WebhookHandler) Ping(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.PingWebhook(r.Context(), id)
+	if err != nil {
+		respondWebhookError(w, err, id, "failed to ping webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, 0, "success", delivery)
+}
+
+// parseWebhookID 解析路径中的订阅ID
+func parseWebhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, 40001, "invalid webhook id")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondWebhookError 订阅不存在返回404，其余返回400
+func respondWebhookError(w http.ResponseWriter, err error, id int64, msg string) {
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		respondError(w, http.StatusNotFound, 40400, err.Error())
+		return
+	}
+
+	logger.Logger.Error().Err(err).Int64("subscription_id", id).Msg(msg)
+	respondError(w, http.StatusBadRequest, 40001, err.Error())
+}
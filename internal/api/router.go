@@ -9,26 +9,42 @@ import (
 	"github.com/hanxuanyu/gitcodestatic/internal/api/handlers"
 	"github.com/hanxuanyu/gitcodestatic/internal/service"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // Router 路由配置
 type Router struct {
-	repoHandler  *handlers.RepoHandler
-	statsHandler *handlers.StatsHandler
-	taskHandler  *handlers.TaskHandler
-	webDir       string
-	webEnabled   bool
+	repoHandler    *handlers.RepoHandler
+	statsHandler   *handlers.StatsHandler
+	taskHandler    *handlers.TaskHandler
+	teamHandler    *handlers.TeamHandler
+	credHandler    *handlers.CredentialHandler
+	commitHandler  *handlers.CommitHandler
+	reportHandler  *handlers.ReportHandler
+	hookHandler    *handlers.HookHandler
+	webhookHandler *handlers.WebhookHandler
+	webDir         string
+	webEnabled     bool
 }
 
 // NewRouter 创建路由
-func NewRouter(repoService *service.RepoService, statsService *service.StatsService, store storage.Store, webDir string, webEnabled bool) *Router {
+func NewRouter(repoService *service.RepoService, statsService *service.StatsService, aggregateService *service.AggregateService,
+	teamService *service.TeamService, credentialService *service.CredentialService,
+	commitService *service.CommitService, reportService *service.ReportService,
+	hookService *service.HookService, webhookService *service.WebhookService, taskService *service.TaskService, broadcaster *worker.Broadcaster, store storage.Store, webDir string, webEnabled bool) *Router {
 	return &Router{
-		repoHandler:  handlers.NewRepoHandler(repoService),
-		statsHandler: handlers.NewStatsHandler(statsService, store),
-		taskHandler:  handlers.NewTaskHandler(store),
-		webDir:       webDir,
-		webEnabled:   webEnabled,
+		repoHandler:    handlers.NewRepoHandler(repoService),
+		statsHandler:   handlers.NewStatsHandler(statsService, aggregateService, store),
+		taskHandler:    handlers.NewTaskHandler(store, taskService, broadcaster),
+		teamHandler:    handlers.NewTeamHandler(teamService),
+		credHandler:    handlers.NewCredentialHandler(credentialService),
+		commitHandler:  handlers.NewCommitHandler(commitService),
+		reportHandler:  handlers.NewReportHandler(reportService),
+		hookHandler:    handlers.NewHookHandler(hookService),
+		webhookHandler: handlers.NewWebhookHandler(webhookService),
+		webDir:         webDir,
+		webEnabled:     webEnabled,
 	}
 }
 
@@ -70,9 +86,16 @@ func (rt *Router) Setup() http.Handler {
 			r.Get("/", rt.repoHandler.List)
 			r.Get("/{id}", rt.repoHandler.Get)
 			r.Get("/{id}/branches", rt.repoHandler.GetBranches)
+			r.Get("/{id}/refs", rt.repoHandler.ListRefs)
+			r.Get("/{id}/commits", rt.commitHandler.List)
+			r.Get("/{id}/commits/{sha}", rt.commitHandler.Get)
 			r.Post("/{id}/switch-branch", rt.repoHandler.SwitchBranch)
 			r.Post("/{id}/update", rt.repoHandler.Update)
 			r.Post("/{id}/reset", rt.repoHandler.Reset)
+			r.Put("/{id}/labels", rt.repoHandler.SetLabels)
+			r.Put("/{id}/sync", rt.repoHandler.SetSync)
+			r.Put("/{id}/credential", rt.repoHandler.SetCredential)
+			r.Delete("/{id}/credential", rt.repoHandler.RemoveCredential)
 			r.Delete("/{id}", rt.repoHandler.Delete)
 		})
 
@@ -81,6 +104,9 @@ func (rt *Router) Setup() http.Handler {
 			r.Post("/calculate", rt.statsHandler.Calculate)
 			r.Get("/result", rt.statsHandler.QueryResult)
 			r.Get("/commit-count", rt.statsHandler.CountCommits)
+			r.Post("/compare", rt.statsHandler.Compare)
+			r.Post("/aggregate", rt.statsHandler.Aggregate)
+			r.Get("/aggregate/{key}", rt.statsHandler.GetAggregate)
 			r.Get("/caches", rt.statsHandler.ListCaches)
 			r.Delete("/caches/clear", rt.statsHandler.ClearAllCaches)
 		})
@@ -88,9 +114,55 @@ func (rt *Router) Setup() http.Handler {
 		// 任务
 		r.Route("/tasks", func(r chi.Router) {
 			r.Get("/", rt.taskHandler.List)
+			r.Get("/stream", rt.taskHandler.Stream)
+			r.Get("/{id}/stream", rt.taskHandler.StreamTask)
+			r.Post("/{id}/cancel", rt.taskHandler.Cancel)
 			r.Delete("/clear", rt.taskHandler.ClearAllTasks)
 			r.Delete("/clear-completed", rt.taskHandler.ClearCompletedTasks)
 		})
+
+		// 团队
+		r.Route("/teams", func(r chi.Router) {
+			r.Get("/", rt.teamHandler.List)
+			r.Post("/", rt.teamHandler.Create)
+			r.Put("/{id}", rt.teamHandler.Update)
+			r.Delete("/{id}", rt.teamHandler.Delete)
+		})
+
+		// 凭据
+		r.Route("/credentials", func(r chi.Router) {
+			r.Get("/", rt.credHandler.List)
+			r.Post("/", rt.credHandler.Create)
+			r.Get("/{id}", rt.credHandler.Get)
+			r.Put("/{id}", rt.credHandler.Update)
+			r.Delete("/{id}", rt.credHandler.Delete)
+			r.Post("/{id}/test", rt.credHandler.Test)
+		})
+
+		// 定时报表
+		r.Route("/reports", func(r chi.Router) {
+			r.Get("/", rt.reportHandler.List)
+			r.Post("/", rt.reportHandler.Create)
+			r.Get("/{id}", rt.reportHandler.Get)
+			r.Put("/{id}", rt.reportHandler.Update)
+			r.Delete("/{id}", rt.reportHandler.Delete)
+			r.Post("/{id}/run", rt.reportHandler.Run)
+			r.Get("/{id}/runs", rt.reportHandler.ListRuns)
+		})
+
+		// 代码托管平台webhook
+		r.Post("/hooks/{provider}", rt.hookHandler.Receive)
+
+		// 事件订阅
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Get("/", rt.webhookHandler.List)
+			r.Post("/", rt.webhookHandler.Create)
+			r.Get("/{id}", rt.webhookHandler.Get)
+			r.Put("/{id}", rt.webhookHandler.Update)
+			r.Delete("/{id}", rt.webhookHandler.Delete)
+			r.Get("/{id}/deliveries", rt.webhookHandler.ListDeliveries)
+			r.Post("/{id}/ping", rt.webhookHandler.Ping)
+		})
 	})
 
 	return r
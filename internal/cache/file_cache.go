@@ -67,10 +67,10 @@ func (c *FileCache) Get(ctx context.Context, cacheKey string) (*models.StatsResu
 
 // Set 设置缓存
 func (c *FileCache) Set(ctx context.Context, repoID int64, branch string, constraint *models.StatsConstraint,
-	commitHash string, stats *models.Statistics) error {
+	commitHash, teamsVersion string, stats *models.Statistics) error {
 
 	// 生成缓存键
-	cacheKey := GenerateCacheKey(repoID, branch, constraint, commitHash)
+	cacheKey := GenerateCacheKey(repoID, branch, constraint, commitHash, teamsVersion)
 
 	// 保存统计结果到文件
 	resultPath := filepath.Join(c.statsDir, cacheKey+".json.gz")
@@ -91,6 +91,7 @@ func (c *FileCache) Set(ctx context.Context, repoID int64, branch string, constr
 		ConstraintType:  constraint.Type,
 		ConstraintValue: SerializeConstraint(constraint),
 		CommitHash:      commitHash,
+		TeamsVersion:    teamsVersion,
 		ResultPath:      resultPath,
 		ResultSize:      fileInfo.Size(),
 		CacheKey:        cacheKey,
@@ -123,8 +124,63 @@ func (c *FileCache) InvalidateByRepoID(ctx context.Context, repoID int64) error
 	return nil
 }
 
+// GetAggregate 获取跨仓库聚合结果
+func (c *FileCache) GetAggregate(ctx context.Context, aggregateKey string) (*models.AggregateResult, error) {
+	resultPath := c.aggregatePath(aggregateKey)
+
+	fileInfo, err := os.Stat(resultPath)
+	if os.IsNotExist(err) {
+		return nil, nil // 缓存不存在
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat aggregate file: %w", err)
+	}
+
+	var result models.AggregateResult
+	if err := c.loadFromFile(resultPath, &result); err != nil {
+		logger.Logger.Error().Err(err).Str("aggregate_key", aggregateKey).Msg("failed to load aggregate from file")
+		return nil, err
+	}
+
+	cachedAt := fileInfo.ModTime()
+	result.CacheHit = true
+	result.CachedAt = &cachedAt
+
+	logger.Logger.Info().Str("aggregate_key", aggregateKey).Msg("aggregate cache hit")
+
+	return &result, nil
+}
+
+// SetAggregate 保存跨仓库聚合结果
+func (c *FileCache) SetAggregate(ctx context.Context, aggregateKey string, result *models.AggregateResult) error {
+	// 先写临时文件再重命名，避免读到写了一半的结果
+	resultPath := c.aggregatePath(aggregateKey)
+	tmpPath := resultPath + ".tmp"
+	if err := c.saveToFile(result, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save aggregate to file: %w", err)
+	}
+	if err := os.Rename(tmpPath, resultPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save aggregate to file: %w", err)
+	}
+
+	logger.Logger.Info().Str("aggregate_key", aggregateKey).Msg("aggregate cache saved")
+	return nil
+}
+
+// aggregatePath 聚合结果文件路径
+func (c *FileCache) aggregatePath(aggregateKey string) string {
+	return filepath.Join(c.statsDir, "aggregates", aggregateKey+".json.gz")
+}
+
 // saveStatsToFile 保存统计结果到文件（gzip压缩）
 func (c *FileCache) saveStatsToFile(stats *models.Statistics, filePath string) error {
+	return c.saveToFile(stats, filePath)
+}
+
+// saveToFile 将对象编码为JSON并以gzip压缩保存
+func (c *FileCache) saveToFile(v interface{}, filePath string) error {
 	// 确保目录存在
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -144,7 +200,7 @@ func (c *FileCache) saveStatsToFile(stats *models.Statistics, filePath string) e
 
 	// 编码JSON
 	encoder := json.NewEncoder(gzipWriter)
-	if err := encoder.Encode(stats); err != nil {
+	if err := encoder.Encode(v); err != nil {
 		return fmt.Errorf("failed to encode stats: %w", err)
 	}
 
@@ -153,26 +209,35 @@ func (c *FileCache) saveStatsToFile(stats *models.Statistics, filePath string) e
 
 // loadStatsFromFile 从文件加载统计结果
 func (c *FileCache) loadStatsFromFile(filePath string) (*models.Statistics, error) {
+	var stats models.Statistics
+	if err := c.loadFromFile(filePath, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// loadFromFile 从gzip压缩的JSON文件解码对象
+func (c *FileCache) loadFromFile(filePath string, v interface{}) error {
 	// 打开文件
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
 	// 创建gzip reader
 	gzipReader, err := gzip.NewReader(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer gzipReader.Close()
 
 	// 解码JSON
-	var stats models.Statistics
 	decoder := json.NewDecoder(gzipReader)
-	if err := decoder.Decode(&stats); err != nil {
-		return nil, fmt.Errorf("failed to decode stats: %w", err)
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode stats: %w", err)
 	}
 
-	return &stats, nil
+	return nil
 }
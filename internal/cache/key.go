@@ -9,7 +9,7 @@ import (
 )
 
 // GenerateCacheKey 生成缓存键
-func GenerateCacheKey(repoID int64, branch string, constraint *models.StatsConstraint, commitHash string) string {
+func GenerateCacheKey(repoID int64, branch string, constraint *models.StatsConstraint, commitHash, teamsVersion string) string {
 	var constraintStr string
 
 	if constraint != nil {
@@ -20,8 +20,8 @@ func GenerateCacheKey(repoID int64, branch string, constraint *models.StatsConst
 		}
 	}
 
-	data := fmt.Sprintf("repo:%d|branch:%s|constraint:%s|commit:%s",
-		repoID, branch, constraintStr, commitHash)
+	data := fmt.Sprintf("repo:%d|branch:%s|constraint:%s|commit:%s|teams:%s",
+		repoID, branch, constraintStr, commitHash, teamsVersion)
 
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
@@ -18,8 +18,11 @@ type Config struct {
 	Cache     CacheConfig     `yaml:"cache"`
 	Security  SecurityConfig  `yaml:"security"`
 	Git       GitConfig       `yaml:"git"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks"`
 	Log       LogConfig       `yaml:"log"`
 	Metrics   MetricsConfig   `yaml:"metrics"`
+	Teams     []TeamConfig    `yaml:"teams"`
 }
 
 // ServerConfig 服务器配置
@@ -39,7 +42,7 @@ type WebConfig struct {
 // WorkspaceConfig 工作空间配置
 type WorkspaceConfig struct {
 	BaseDir  string `yaml:"base_dir"`
-	CacheDir string `yaml:"cache_dir"`
+	CacheDir string `yaml:"cache_dir"` // 仓库裸镜像目录
 	StatsDir string `yaml:"stats_dir"`
 }
 
@@ -67,11 +70,20 @@ type PostgresConfig struct {
 
 // WorkerConfig Worker配置
 type WorkerConfig struct {
-	CloneWorkers   int `yaml:"clone_workers"`
-	PullWorkers    int `yaml:"pull_workers"`
-	StatsWorkers   int `yaml:"stats_workers"`
-	GeneralWorkers int `yaml:"general_workers"`
-	QueueBuffer    int `yaml:"queue_buffer"`
+	CloneWorkers   int                    `yaml:"clone_workers"`
+	PullWorkers    int                    `yaml:"pull_workers"`
+	StatsWorkers   int                    `yaml:"stats_workers"`
+	GeneralWorkers int                    `yaml:"general_workers"`
+	QueueBuffer    int                    `yaml:"queue_buffer"`
+	Retry          map[string]RetryConfig `yaml:"retry"` // 按任务类型配置的失败重试，未配置的类型不重试
+}
+
+// RetryConfig 任务重试策略，只重试网络错误、超时、锁文件等临时失败
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`    // 最多执行次数（含首次）
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration `yaml:"max_backoff"`     // 重试等待时间上限
+	Jitter         float64       `yaml:"jitter"`          // 等待时间随机浮动的比例，0.2表示±20%
 }
 
 // CacheConfig 缓存配置
@@ -84,13 +96,79 @@ type CacheConfig struct {
 
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	EncryptionKey string `yaml:"encryption_key"`
+	EncryptionKey  string   `yaml:"encryption_key"`
+	LocalRepoRoots []string `yaml:"local_repo_roots"` // 允许原地分析的本地仓库根目录，为空时禁用本地仓库
 }
 
 // GitConfig Git配置
 type GitConfig struct {
-	CommandPath     string `yaml:"command_path"`
-	FallbackToGoGit bool   `yaml:"fallback_to_gogit"`
+	Backend         string        `yaml:"backend"` // auto/cmd/gogit
+	CommandPath     string        `yaml:"command_path"`
+	FallbackToGoGit *bool         `yaml:"fallback_to_gogit"` // backend=auto 且git命令不可用时使用go-git，未配置时默认开启
+	CloneTimeout    time.Duration `yaml:"clone_timeout"`     // 克隆/重置任务超时
+	PullTimeout     time.Duration `yaml:"pull_timeout"`      // 拉取任务超时
+}
+
+// Git Backend constants
+const (
+	GitBackendAuto  = "auto"
+	GitBackendCmd   = "cmd"
+	GitBackendGoGit = "gogit"
+)
+
+// SchedulerConfig 定时任务配置
+type SchedulerConfig struct {
+	CheckInterval time.Duration `yaml:"check_interval"` // 检查到期任务的周期
+	Sync          SyncConfig    `yaml:"sync"`
+	Reports       ReportsConfig `yaml:"reports"`
+}
+
+// SyncConfig 仓库自动同步配置
+type SyncConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Interval           time.Duration `yaml:"interval"`              // 默认同步间隔，仓库可单独覆盖
+	Jitter             time.Duration `yaml:"jitter"`                // 随机延后0~jitter，避免所有仓库同时拉取
+	SkipIfPulledWithin time.Duration `yaml:"skip_if_pulled_within"` // 最近这段时间内拉取过则跳过本次同步
+}
+
+// ReportsConfig 定时报表配置
+type ReportsConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	PullIfOlderThan time.Duration `yaml:"pull_if_older_than"` // 运行前拉取上次拉取早于该时长的仓库，0表示每次都拉取
+}
+
+// WebhooksConfig webhook配置
+type WebhooksConfig struct {
+	Inbound  InboundWebhookConfig  `yaml:"inbound"`
+	Outbound OutboundWebhookConfig `yaml:"outbound"`
+}
+
+// InboundWebhookConfig 接收代码托管平台push事件的配置，未配置密钥的平台拒绝接收
+type InboundWebhookConfig struct {
+	GitHubSecret   string              `yaml:"github_secret"`    // GitHub webhook secret，用于校验 X-Hub-Signature-256
+	GitLabToken    string              `yaml:"gitlab_token"`     // GitLab secret token，与 X-Gitlab-Token 比较
+	GiteaSecret    string              `yaml:"gitea_secret"`     // Gitea webhook secret，用于校验 X-Gitea-Signature
+	MaxPayloadSize int64               `yaml:"max_payload_size"` // 请求体大小上限（字节）
+	Recalculate    []RecalculateConfig `yaml:"recalculate"`      // 拉取完成后对推送分支重新计算的统计
+}
+
+// RecalculateConfig 推送后重新计算的统计约束，relative 与 type 二选一
+type RecalculateConfig struct {
+	Relative string `yaml:"relative"` // 相对时间范围，如 last_30_days
+	Type     string `yaml:"type"`     // date_range 或 commit_limit
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	Limit    int    `yaml:"limit"`
+}
+
+// OutboundWebhookConfig 事件订阅投递配置
+type OutboundWebhookConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Timeout        time.Duration `yaml:"timeout"`         // 单次投递的请求超时
+	MaxAttempts    int           `yaml:"max_attempts"`    // 最多投递次数（含首次）
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration `yaml:"max_backoff"`     // 重试等待时间上限
+	Concurrency    int           `yaml:"concurrency"`     // 同时进行的投递数
 }
 
 // LogConfig 日志配置
@@ -106,6 +184,13 @@ type MetricsConfig struct {
 	Path    string `yaml:"path"`
 }
 
+// TeamConfig 团队配置
+type TeamConfig struct {
+	Name     string   `yaml:"name"`
+	Emails   []string `yaml:"emails"`   // 精确匹配的邮箱
+	Patterns []string `yaml:"patterns"` // 邮箱正则
+}
+
 // LoadConfig 从文件加载配置
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -127,6 +212,16 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.Storage.SQLite.Path = dbPath
 	}
 
+	if secret := os.Getenv("WEBHOOK_GITHUB_SECRET"); secret != "" {
+		cfg.Webhooks.Inbound.GitHubSecret = secret
+	}
+	if token := os.Getenv("WEBHOOK_GITLAB_TOKEN"); token != "" {
+		cfg.Webhooks.Inbound.GitLabToken = token
+	}
+	if secret := os.Getenv("WEBHOOK_GITEA_SECRET"); secret != "" {
+		cfg.Webhooks.Inbound.GiteaSecret = secret
+	}
+
 	// 设置默认值
 	setDefaults(&cfg)
 
@@ -180,6 +275,28 @@ func setDefaults(cfg *Config) {
 	if cfg.Worker.QueueBuffer == 0 {
 		cfg.Worker.QueueBuffer = 100
 	}
+	if cfg.Worker.Retry == nil {
+		cfg.Worker.Retry = map[string]RetryConfig{
+			"clone": {MaxAttempts: 3},
+			"pull":  {MaxAttempts: 3},
+			"reset": {MaxAttempts: 3},
+		}
+	}
+	for taskType, retry := range cfg.Worker.Retry {
+		if retry.MaxAttempts == 0 {
+			retry.MaxAttempts = 1
+		}
+		if retry.InitialBackoff == 0 {
+			retry.InitialBackoff = 30 * time.Second
+		}
+		if retry.MaxBackoff == 0 {
+			retry.MaxBackoff = 10 * time.Minute
+		}
+		if retry.Jitter == 0 {
+			retry.Jitter = 0.2
+		}
+		cfg.Worker.Retry[taskType] = retry
+	}
 
 	if cfg.Cache.MaxTotalSize == 0 {
 		cfg.Cache.MaxTotalSize = 10 * 1024 * 1024 * 1024 // 10GB
@@ -194,8 +311,45 @@ func setDefaults(cfg *Config) {
 		cfg.Cache.CleanupInterval = 3600 // 1 hour
 	}
 
-	if cfg.Git.FallbackToGoGit {
-		// Default: allow fallback
+	if cfg.Git.Backend == "" {
+		cfg.Git.Backend = GitBackendAuto
+	}
+	if cfg.Git.FallbackToGoGit == nil {
+		// 没有git命令的精简镜像不需要配置文件也能工作
+		fallback := true
+		cfg.Git.FallbackToGoGit = &fallback
+	}
+	if cfg.Git.CloneTimeout == 0 {
+		cfg.Git.CloneTimeout = 10 * time.Minute
+	}
+	if cfg.Git.PullTimeout == 0 {
+		cfg.Git.PullTimeout = 5 * time.Minute
+	}
+
+	if cfg.Scheduler.CheckInterval == 0 {
+		cfg.Scheduler.CheckInterval = time.Minute
+	}
+	if cfg.Scheduler.Sync.Interval == 0 {
+		cfg.Scheduler.Sync.Interval = time.Hour
+	}
+
+	if cfg.Webhooks.Inbound.MaxPayloadSize == 0 {
+		cfg.Webhooks.Inbound.MaxPayloadSize = 5 * 1024 * 1024 // 5MB
+	}
+	if cfg.Webhooks.Outbound.Timeout == 0 {
+		cfg.Webhooks.Outbound.Timeout = 10 * time.Second
+	}
+	if cfg.Webhooks.Outbound.MaxAttempts == 0 {
+		cfg.Webhooks.Outbound.MaxAttempts = 6
+	}
+	if cfg.Webhooks.Outbound.InitialBackoff == 0 {
+		cfg.Webhooks.Outbound.InitialBackoff = 10 * time.Second
+	}
+	if cfg.Webhooks.Outbound.MaxBackoff == 0 {
+		cfg.Webhooks.Outbound.MaxBackoff = 10 * time.Minute
+	}
+	if cfg.Webhooks.Outbound.Concurrency == 0 {
+		cfg.Webhooks.Outbound.Concurrency = 4
 	}
 
 	if cfg.Log.Level == "" {
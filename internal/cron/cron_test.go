@@ -0,0 +1,117 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 2024-01-01 是周一
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"empty", ""},
+		{"too few fields", "* * * *"},
+		{"too many fields", "* * * * * *"},
+		{"minute out of range", "60 * * * *"},
+		{"hour out of range", "* 24 * * *"},
+		{"day of month zero", "* * 0 * *"},
+		{"month out of range", "* * * 13 *"},
+		{"day of week out of range", "* * * * 8"},
+		{"reversed range", "5-1 * * * *"},
+		{"zero step", "*/0 * * * *"},
+		{"negative step", "*/-1 * * * *"},
+		{"unknown name", "* * * foo *"},
+		{"unknown macro", "@sometimes"},
+		{"february 30th never fires", "0 0 30 2 *"},
+		{"april 31st never fires", "0 0 31 4 *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", base, date(2024, 1, 1, 0, 1)},
+		{"strictly after", "0 0 * * *", base, date(2024, 1, 2, 0, 0)},
+		{"seconds truncated", "* * * * *", base.Add(30 * time.Second), date(2024, 1, 1, 0, 1)},
+
+		{"hourly macro", "@hourly", base, date(2024, 1, 1, 1, 0)},
+		{"daily macro", "@daily", base, date(2024, 1, 2, 0, 0)},
+		{"midnight macro", "@midnight", base, date(2024, 1, 2, 0, 0)},
+		{"weekly macro", "@weekly", base, date(2024, 1, 7, 0, 0)},
+		{"monthly macro", "@monthly", base, date(2024, 2, 1, 0, 0)},
+		{"yearly macro", "@yearly", base, date(2025, 1, 1, 0, 0)},
+		{"annually macro", "@annually", base, date(2025, 1, 1, 0, 0)},
+		{"macro case insensitive", " @DAILY ", base, date(2024, 1, 2, 0, 0)},
+
+		{"list", "0 6,18 * * *", date(2024, 1, 1, 7, 0), date(2024, 1, 1, 18, 0)},
+		{"range", "30 9 * * 1-5", base, date(2024, 1, 1, 9, 30)},
+		{"range skips weekend", "30 9 * * 1-5", date(2024, 1, 5, 10, 0), date(2024, 1, 8, 9, 30)},
+		{"step", "*/15 * * * *", date(2024, 1, 1, 0, 16), date(2024, 1, 1, 0, 30)},
+		{"range with step", "0 8-18/5 * * *", date(2024, 1, 1, 9, 0), date(2024, 1, 1, 13, 0)},
+		{"value with step", "0 10/6 * * *", date(2024, 1, 1, 17, 0), date(2024, 1, 1, 22, 0)},
+		{"month names", "0 0 1 jan,jul *", base, date(2024, 7, 1, 0, 0)},
+		{"day names", "0 0 * * SAT", base, date(2024, 1, 6, 0, 0)},
+
+		{"0 is sunday", "0 0 * * 0", base, date(2024, 1, 7, 0, 0)},
+		{"7 is sunday", "0 0 * * 7", base, date(2024, 1, 7, 0, 0)},
+		{"range ending in 7", "0 0 * * 6-7", base, date(2024, 1, 6, 0, 0)},
+
+		// 日和周都受限时满足其一即可
+		{"dom or dow matches dow", "0 0 13 * 5", base, date(2024, 1, 5, 0, 0)},
+		{"dom or dow matches dom", "0 0 13 * 5", date(2024, 1, 12, 0, 0), date(2024, 1, 13, 0, 0)},
+		// 以 * 开头的字段不限制，另一个字段必须满足
+		{"dom step and dow", "0 0 */2 * 1", base, date(2024, 1, 15, 0, 0)},
+		{"dom and dow step", "0 0 2 * */7", base, date(2024, 6, 2, 0, 0)},
+		{"dom star and dow", "0 0 * * 3", base, date(2024, 1, 3, 0, 0)},
+
+		{"leap day", "0 0 29 2 *", date(2024, 3, 1, 0, 0), date(2028, 2, 29, 0, 0)},
+		{"31st skips short months", "0 0 31 * *", date(2024, 1, 31, 0, 0), date(2024, 3, 31, 0, 0)},
+		{"year rollover", "59 23 31 12 *", base, date(2024, 12, 31, 23, 59)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Next(tt.from))
+		})
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	s, err := Parse("0 9 * * *")
+	require.NoError(t, err)
+
+	next := s.Next(time.Date(2024, 1, 1, 10, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, loc), next)
+	assert.Equal(t, loc, next.Location())
+}
+
+func TestNextNeverFires(t *testing.T) {
+	// 直接构造的调度不经过Parse校验，Next找不到触发时间时返回零值
+	s := &Schedule{minute: 1, hour: 1, dom: 1 << 30, month: 1 << 2, dow: 1<<7 - 1, dowStar: true}
+	assert.True(t, s.Next(base).IsZero())
+}
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
@@ -0,0 +1,255 @@
+package git
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// 凭据通过环境变量传给凭据助手，避免出现在命令行参数和仓库配置中
+const (
+	envAuthUsername = "GITCODESTATIC_GIT_USERNAME"
+	envAuthPassword = "GITCODESTATIC_GIT_PASSWORD"
+)
+
+// credentialHelper 进程内凭据助手，只响应get请求，从环境变量读取用户名和密码
+const credentialHelper = `!f() { test "$1" = get || exit 0; echo "username=$` + envAuthUsername + `"; echo "password=$` + envAuthPassword + `"; }; f`
+
+// ErrCredentialHostMismatch 仓库地址的主机与凭据绑定的主机不一致
+var ErrCredentialHostMismatch = errors.New("repository host does not match credential host")
+
+// authSession 单次git调用的认证环境
+//
+// 认证配置通过 GIT_CONFIG_COUNT/GIT_CONFIG_KEY_n/GIT_CONFIG_VALUE_n 注入（需要git 2.31+），
+// 只对本次调用生效，不会写入仓库配置；clone、fetch、pull 使用同一套方式认证。
+// SSH私钥等敏感文件只在本次调用期间存在于临时目录中，调用结束后必须执行Cleanup。
+type authSession struct {
+	dir     string
+	env     []string
+	configs [][2]string
+}
+
+// newAuthSession 根据凭据准备认证环境，凭据为空时返回空会话
+func newAuthSession(cred *models.Credential) (*authSession, error) {
+	session := &authSession{}
+	if cred == nil {
+		return session, nil
+	}
+
+	switch cred.AuthType {
+	case models.AuthTypeSSH:
+		if err := session.setupSSH(cred); err != nil {
+			session.Cleanup()
+			return nil, err
+		}
+	case models.AuthTypeToken:
+		if cred.Password == "" {
+			return nil, fmt.Errorf("token credential requires a token")
+		}
+		// 带用户名的令牌（如GitHub/GitLab个人访问令牌）按用户名+密码方式认证，否则作为Bearer令牌发送
+		if cred.Username != "" {
+			session.useCredentialHelper(cred.Username, cred.Password)
+		} else {
+			session.configs = append(session.configs, [2]string{"http.extraHeader", "Authorization: Bearer " + cred.Password})
+		}
+	default:
+		if cred.Username != "" {
+			session.useCredentialHelper(cred.Username, cred.Password)
+		}
+	}
+
+	return session, nil
+}
+
+// useCredentialHelper 清空已配置的凭据助手，只使用进程内凭据助手
+func (s *authSession) useCredentialHelper(username, password string) {
+	s.configs = append(s.configs,
+		[2]string{"credential.helper", ""},
+		[2]string{"credential.helper", credentialHelper},
+	)
+	s.env = append(s.env,
+		envAuthUsername+"="+username,
+		envAuthPassword+"="+password,
+	)
+}
+
+// setupSSH 写入临时私钥并通过 GIT_SSH_COMMAND 使用
+func (s *authSession) setupSSH(cred *models.Credential) error {
+	key, err := DecodeSSHKey(cred.PrivateKey, cred.Passphrase)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "gitcodestatic-ssh-")
+	if err != nil {
+		return fmt.Errorf("failed to create ssh temp dir: %w", err)
+	}
+	s.dir = dir
+
+	keyFile := filepath.Join(dir, "id")
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		return fmt.Errorf("failed to write ssh key: %w", err)
+	}
+
+	sshArgs := []string{
+		"ssh",
+		"-i", shellQuote(keyFile),
+		"-o", "IdentitiesOnly=yes",
+		"-o", "BatchMode=yes",
+	}
+
+	// 提供了known_hosts时只按它校验主机密钥；否则按系统known_hosts严格校验，
+	// 凭据显式开启首次信任时才接受并记录未知主机
+	if cred.KnownHosts != "" {
+		knownHostsFile := filepath.Join(dir, "known_hosts")
+		if err := os.WriteFile(knownHostsFile, []byte(cred.KnownHosts), 0600); err != nil {
+			return fmt.Errorf("failed to write known_hosts: %w", err)
+		}
+		sshArgs = append(sshArgs,
+			"-o", "UserKnownHostsFile="+shellQuote(knownHostsFile),
+			"-o", "StrictHostKeyChecking=yes",
+		)
+	} else if cred.TrustNewHosts {
+		sshArgs = append(sshArgs, "-o", "StrictHostKeyChecking=accept-new")
+	} else {
+		sshArgs = append(sshArgs, "-o", "StrictHostKeyChecking=yes")
+	}
+
+	s.env = append(s.env, "GIT_SSH_COMMAND="+strings.Join(sshArgs, " "))
+
+	return nil
+}
+
+// Env 返回git子进程的完整环境变量
+func (s *authSession) Env() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0") // 禁止交互式提示
+	env = append(env, s.env...)
+
+	if len(s.configs) > 0 {
+		env = append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(len(s.configs)))
+		for i, kv := range s.configs {
+			env = append(env,
+				fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]),
+				fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]),
+			)
+		}
+	}
+
+	return env
+}
+
+// Cleanup 删除本次调用生成的临时文件
+func (s *authSession) Cleanup() {
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// credentialKey 上下文中仓库凭据的键
+type credentialKey struct{}
+
+// WithCredential 返回携带仓库凭据的上下文
+//
+// 部分克隆在读取日志、提交和diff时会按需从远程拉取缺失的对象，读取类命令通过
+// CommandEnv 使用与 clone/fetch 相同的认证方式。
+func WithCredential(ctx context.Context, cred *models.Credential) context.Context {
+	if cred == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialKey{}, cred)
+}
+
+// CommandEnv 返回读取类git命令的环境变量，调用结束后必须执行返回的清理函数
+//
+// 始终禁止交互式提示，上下文携带凭据时注入认证配置。
+func CommandEnv(ctx context.Context) ([]string, func(), error) {
+	cred, _ := ctx.Value(credentialKey{}).(*models.Credential)
+	auth, err := newAuthSession(cred)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare credentials: %w", err)
+	}
+	return auth.Env(), auth.Cleanup, nil
+}
+
+// RepoHost 返回仓库地址的主机名（小写，不含端口和用户名），本地仓库返回空
+func RepoHost(repoURL string) string {
+	if IsLocalURL(repoURL) {
+		return ""
+	}
+	host, _ := splitRepoURL(repoURL)
+	if host == "local" {
+		return ""
+	}
+	return stripPort(host)
+}
+
+// NormalizeHost 规范化凭据绑定的主机，可以填写主机名，也可以直接填写仓库地址
+func NormalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if strings.Contains(host, "://") || strings.Contains(host, "@") {
+		return RepoHost(host)
+	}
+	return stripPort(strings.ToLower(strings.TrimSuffix(host, "/")))
+}
+
+// stripPort 去掉主机中的端口，同一主机的 https 和 ssh 地址使用同一凭据
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.Trim(host, "[]")
+}
+
+// CheckCredentialHost 校验凭据只用于其绑定主机上的仓库，避免令牌或私钥被发送到任意主机
+func CheckCredentialHost(cred *models.Credential, repoURL string) error {
+	if cred.Host == "" {
+		return fmt.Errorf("%w: credential %s is not bound to a host", ErrCredentialHostMismatch, cred.ID)
+	}
+	if host := RepoHost(repoURL); host != cred.Host {
+		return fmt.Errorf("%w: credential is bound to %s, repository is on %q", ErrCredentialHostMismatch, cred.Host, host)
+	}
+	return nil
+}
+
+// DecodeSSHKey 解析SSH私钥，返回不带口令的PEM内容
+//
+// 有口令的私钥在进程内解密，避免git调用ssh时需要交互输入口令。
+func DecodeSSHKey(privateKey, passphrase string) ([]byte, error) {
+	if privateKey == "" {
+		return nil, fmt.Errorf("ssh private key is required")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	if passphrase != "" {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		key, err = ssh.ParseRawPrivateKey([]byte(privateKey))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh private key: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ssh private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// shellQuote 为GIT_SSH_COMMAND中的路径加引号（git通过shell执行该命令）
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -1,17 +1,32 @@
 package git
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/progress"
 )
 
+// ErrRefNotFound 引用不存在
+var ErrRefNotFound = errors.New("ref not found")
+
+// interruptGracePeriod 取消clone/fetch后等待git自行退出的时间，超时后强制结束
+const interruptGracePeriod = 10 * time.Second
+
+// refPattern 允许的引用名字符
+var refPattern = regexp.MustCompile(`^[A-Za-z0-9._/@{}^~+-]+$`)
+
 // CmdGitManager 基于git命令的实现
 type CmdGitManager struct {
 	gitPath string
@@ -32,18 +47,29 @@ func (m *CmdGitManager) IsAvailable() bool {
 	return err == nil
 }
 
-// Clone 克隆仓库
-func (m *CmdGitManager) Clone(ctx context.Context, url, localPath string, cred *models.Credential) error {
-	// 注入凭据到URL（如果有）
-	cloneURL := url
-	if cred != nil {
-		cloneURL = m.injectCredentials(url, cred)
+// Clone 克隆仓库为裸镜像
+//
+// 裸镜像只包含对象和引用，远程分支直接映射到 refs/heads/*，统计、提交浏览和切换分支
+// 都直接基于引用进行，不需要工作区。
+//
+// blobless/treeless 为部分克隆，统计读取numstat时git会按需从远程拉取缺失的对象。
+func (m *CmdGitManager) Clone(ctx context.Context, url, localPath string, cred *models.Credential, opts CloneOptions) error {
+	auth, err := newAuthSession(cred)
+	if err != nil {
+		return fmt.Errorf("failed to prepare credentials: %w", err)
+	}
+	defer auth.Cleanup()
+
+	args := append([]string{"clone", "--bare"}, opts.cloneArgs()...)
+	if progress.Enabled(ctx) {
+		args = append(args, "--progress")
 	}
+	args = append(args, url, localPath)
 
-	cmd := exec.CommandContext(ctx, m.gitPath, "clone", cloneURL, localPath)
-	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0") // 禁止交互式提示
+	cmd := exec.CommandContext(ctx, m.gitPath, args...)
+	cmd.Env = auth.Env()
 
-	output, err := cmd.CombinedOutput()
+	output, err := runWithProgress(ctx, cmd)
 	if err != nil {
 		// 脱敏日志
 		sanitizedURL := sanitizeURL(url)
@@ -52,30 +78,63 @@ func (m *CmdGitManager) Clone(ctx context.Context, url, localPath string, cred *
 			Str("url", sanitizedURL).
 			Str("output", string(output)).
 			Msg("failed to clone repository")
-		return fmt.Errorf("failed to clone repository: %w", err)
+		return fmt.Errorf("failed to clone repository: %w", newCommandError(err, output))
+	}
+
+	// 裸克隆默认不配置fetch refspec，补上分支镜像规则以便后续fetch更新所有分支
+	cmd = exec.CommandContext(ctx, m.gitPath, "-C", localPath, "config", "remote.origin.fetch", MirrorFetchRefSpec)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Str("local_path", localPath).
+			Str("output", string(output)).
+			Msg("failed to configure mirror refspec")
+		return fmt.Errorf("failed to configure mirror refspec: %w", newCommandError(err, output))
 	}
 
 	logger.Logger.Info().
 		Str("url", sanitizeURL(url)).
 		Str("local_path", localPath).
+		Str("clone_strategy", opts.Strategy).
 		Msg("repository cloned successfully")
 
 	return nil
 }
 
-// Pull 拉取更新
-func (m *CmdGitManager) Pull(ctx context.Context, localPath string, cred *models.Credential) error {
-	cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "pull")
-	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+// Pull 拉取更新，裸镜像使用fetch更新所有分支，旧的工作副本仍使用pull
+func (m *CmdGitManager) Pull(ctx context.Context, localPath string, cred *models.Credential, opts CloneOptions) error {
+	args := []string{"-C", localPath, "pull"}
+	if m.isBare(ctx, localPath) {
+		args = []string{"-C", localPath, "fetch", "--prune", "--tags"}
+	}
+	args = append(args, opts.fetchArgs()...)
+	if progress.Enabled(ctx) {
+		args = append(args, "--progress")
+	}
+	args = append(args, "origin")
+
+	// 旧版本把凭据写进了remote URL，拉取前先清除
+	if err := m.scrubRemoteURL(ctx, localPath); err != nil {
+		return err
+	}
 
-	output, err := cmd.CombinedOutput()
+	auth, err := newAuthSession(cred)
+	if err != nil {
+		return fmt.Errorf("failed to prepare credentials: %w", err)
+	}
+	defer auth.Cleanup()
+
+	cmd := exec.CommandContext(ctx, m.gitPath, args...)
+	cmd.Env = auth.Env()
+
+	output, err := runWithProgress(ctx, cmd)
 	if err != nil {
 		logger.Logger.Error().
 			Err(err).
 			Str("local_path", localPath).
 			Str("output", string(output)).
 			Msg("failed to pull repository")
-		return fmt.Errorf("failed to pull repository: %w", err)
+		return fmt.Errorf("failed to pull repository: %w", newCommandError(err, output))
 	}
 
 	logger.Logger.Info().
@@ -85,9 +144,74 @@ func (m *CmdGitManager) Pull(ctx context.Context, localPath string, cred *models
 	return nil
 }
 
+// runWithProgress 运行git命令并返回合并的输出，context中设置了进度回调时同时解析stderr中的进度
+//
+// context取消时先发送中断信号，git会删除自己创建的 .lock 文件和未完成的临时包后退出；
+// 直接SIGKILL会留下锁文件，导致之后的拉取失败。
+func runWithProgress(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			// 不支持中断信号的平台（如Windows）直接结束进程
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = interruptGracePeriod
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if w := progress.GitWriter(ctx); w != nil {
+		cmd.Stderr = io.MultiWriter(&output, w)
+	}
+
+	err := cmd.Run()
+	return output.Bytes(), err
+}
+
+// LsRemote 列出远程分支，只用于验证访问权限
+func (m *CmdGitManager) LsRemote(ctx context.Context, url string, cred *models.Credential) error {
+	auth, err := newAuthSession(cred)
+	if err != nil {
+		return fmt.Errorf("failed to prepare credentials: %w", err)
+	}
+	defer auth.Cleanup()
+
+	cmd := exec.CommandContext(ctx, m.gitPath, "ls-remote", "--heads", "--", url)
+	cmd.Env = auth.Env()
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("git ls-remote failed: %s", msg)
+	}
+
+	return nil
+}
+
 // Checkout 切换分支
+//
+// 裸镜像没有工作区，切换分支只是把HEAD指向该分支（作为仓库的默认统计分支）。
 func (m *CmdGitManager) Checkout(ctx context.Context, localPath, branch string) error {
-	cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "checkout", branch)
+	if err := ValidateRef(branch); err != nil {
+		return err
+	}
+
+	args := []string{"-C", localPath, "checkout", branch}
+	if m.isBare(ctx, localPath) {
+		ref := "refs/heads/" + strings.TrimPrefix(branch, "origin/")
+		verify := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "show-ref", "--verify", "--quiet", ref)
+		if err := verify.Run(); err != nil {
+			return fmt.Errorf("failed to checkout branch: %w", ErrRefNotFound)
+		}
+		args = []string{"-C", localPath, "symbolic-ref", "HEAD", ref}
+	}
+
+	cmd := exec.CommandContext(ctx, m.gitPath, args...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -134,15 +258,61 @@ func (m *CmdGitManager) GetHeadCommitHash(ctx context.Context, localPath string)
 	return hash, nil
 }
 
+// ResolveRef 将分支、标签或远程引用解析为commit hash
+//
+// 本地不存在同名分支时回退到 origin/<ref>，因此无需切换分支即可统计远程分支。
+func (m *CmdGitManager) ResolveRef(ctx context.Context, localPath, ref string) (string, error) {
+	if err := ValidateRef(ref); err != nil {
+		return "", err
+	}
+
+	candidates := []string{ref}
+	if strings.HasPrefix(ref, "origin/") {
+		// 裸镜像中远程分支即 refs/heads/*
+		candidates = append(candidates, "refs/heads/"+strings.TrimPrefix(ref, "origin/"))
+	} else if !strings.HasPrefix(ref, "refs/") {
+		candidates = append(candidates, "refs/remotes/origin/"+ref)
+	}
+
+	for _, candidate := range candidates {
+		cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+
+		output, err := cmd.Output()
+		if err == nil {
+			return strings.TrimSpace(string(output)), nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+
+	return "", fmt.Errorf("failed to resolve ref %s: %w", ref, ErrRefNotFound)
+}
+
 // CountCommits 统计提交次数
+//
+// 与 ResolveRef 一样接受分支、标签和 origin/<branch>，从解析出的commit开始计数。
 func (m *CmdGitManager) CountCommits(ctx context.Context, localPath, branch, fromDate string) (int, error) {
+	if err := ValidateRef(branch); err != nil {
+		return 0, err
+	}
+
+	hash, err := m.ResolveRef(ctx, localPath, branch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+
 	args := []string{"-C", localPath, "rev-list", "--count"}
 
 	if fromDate != "" {
-		args = append(args, "--since="+fromDate)
+		since, err := ParseDate(fromDate, false)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, "--since="+since.Format(time.RFC3339))
 	}
 
-	args = append(args, branch)
+	args = append(args, hash, "--")
 
 	cmd := exec.CommandContext(ctx, m.gitPath, args...)
 
@@ -162,8 +332,87 @@ func (m *CmdGitManager) CountCommits(ctx context.Context, localPath, branch, fro
 
 // ListBranches 获取仓库分支列表
 func (m *CmdGitManager) ListBranches(ctx context.Context, localPath string) ([]string, error) {
-	// 首先获取远程分支
-	cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "branch", "-r")
+	// 裸镜像的分支即远程分支；旧的工作副本读取远程跟踪分支
+	bare := m.isBare(ctx, localPath)
+	branches, err := m.listBranches(ctx, localPath, bare)
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有远程的本地工作副本（原地分析的本地仓库）读取本地分支
+	if !bare && len(branches) == 0 {
+		return m.listBranches(ctx, localPath, true)
+	}
+
+	return branches, nil
+}
+
+// ListRefs 通过 for-each-ref 列出分支和标签，按提交时间从新到旧排序
+func (m *CmdGitManager) ListRefs(ctx context.Context, localPath, refType, base string) ([]models.Ref, error) {
+	args := append([]string{"-C", localPath, "for-each-ref", "--format=" + refsFormat}, refNamespaces(refType)...)
+	cmd := exec.CommandContext(ctx, m.gitPath, args...)
+
+	output, err := cmd.Output()
+	if err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Str("local_path", localPath).
+			Msg("failed to list refs")
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	refs := parseForEachRef(string(output))
+	sortRefs(refs)
+
+	if base != "" {
+		for i := range refs[:min(len(refs), MaxAheadBehindRefs)] {
+			ahead, behind, err := m.aheadBehind(ctx, localPath, base, refs[i].SHA)
+			if err != nil {
+				return nil, err
+			}
+			refs[i].Ahead = &ahead
+			refs[i].Behind = &behind
+		}
+	}
+
+	return refs, nil
+}
+
+// aheadBehind 计算tip相对base领先和落后的提交数
+func (m *CmdGitManager) aheadBehind(ctx context.Context, localPath, base, tip string) (int, int, error) {
+	if tip == base {
+		return 0, 0, nil
+	}
+
+	cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "rev-list", "--left-right", "--count", base+"..."+tip)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count ahead/behind for %s: %w", tip, err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", string(output))
+	}
+	behind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse rev-list output: %w", err)
+	}
+	ahead, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse rev-list output: %w", err)
+	}
+
+	return ahead, behind, nil
+}
+
+// listBranches 列出本地分支（local=true）或远程跟踪分支
+func (m *CmdGitManager) listBranches(ctx context.Context, localPath string, local bool) ([]string, error) {
+	args := []string{"-C", localPath, "branch", "-r"}
+	if local {
+		args = []string{"-C", localPath, "for-each-ref", "--format=%(refname:short)", "refs/heads"}
+	}
+	cmd := exec.CommandContext(ctx, m.gitPath, args...)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -200,22 +449,59 @@ func (m *CmdGitManager) ListBranches(ctx context.Context, localPath string) ([]s
 	return branches, nil
 }
 
-// injectCredentials 注入凭据到URL
-func (m *CmdGitManager) injectCredentials(url string, cred *models.Credential) string {
-	if cred == nil || cred.Username == "" {
-		return url
+// isBare 判断是否为裸仓库
+func (m *CmdGitManager) isBare(ctx context.Context, localPath string) bool {
+	cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "rev-parse", "--is-bare-repository")
+	output, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// scrubRemoteURL 移除remote URL中的用户名密码
+func (m *CmdGitManager) scrubRemoteURL(ctx context.Context, localPath string) error {
+	cmd := exec.CommandContext(ctx, m.gitPath, "-C", localPath, "config", "--get", "remote.origin.url")
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to read remote url: %w", err)
 	}
 
-	// 简单的URL凭据注入（仅支持https）
-	if strings.HasPrefix(url, "https://") {
-		credentials := cred.Username
-		if cred.Password != "" {
-			credentials += ":" + cred.Password
-		}
-		return strings.Replace(url, "https://", "https://"+credentials+"@", 1)
+	remoteURL := strings.TrimSpace(string(output))
+	cleanURL := stripURLCredentials(remoteURL)
+	if cleanURL == remoteURL {
+		return nil
 	}
 
-	return url
+	cmd = exec.CommandContext(ctx, m.gitPath, "-C", localPath, "remote", "set-url", "origin", cleanURL)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Str("local_path", localPath).
+			Str("output", string(output)).
+			Msg("failed to scrub credentials from remote url")
+		return fmt.Errorf("failed to scrub credentials from remote url: %w", err)
+	}
+
+	logger.Logger.Info().
+		Str("local_path", localPath).
+		Msg("removed embedded credentials from remote url")
+
+	return nil
+}
+
+// stripURLCredentials 移除http(s) URL中的用户信息
+func stripURLCredentials(url string) string {
+	re := regexp.MustCompile(`^(https?://)[^@/]+@`)
+	return re.ReplaceAllString(url, "${1}")
+}
+
+// ValidateRef 校验引用名，防止以选项形式传给git
+func ValidateRef(ref string) error {
+	if ref == "" {
+		return errors.New("ref cannot be empty")
+	}
+	if strings.HasPrefix(ref, "-") || !refPattern.MatchString(ref) || strings.Contains(ref, "..") {
+		return fmt.Errorf("invalid ref: %s", ref)
+	}
+	return nil
 }
 
 // sanitizeURL 脱敏URL（移除用户名密码）
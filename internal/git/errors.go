@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// CommandError git命令执行失败，保留git的错误输出用于展示和判断是否可重试
+type CommandError struct {
+	Err    error
+	Output string // git输出中的 fatal:/error: 行，已脱敏
+}
+
+// newCommandError 从git的合并输出中提取错误信息
+func newCommandError(err error, output []byte) *CommandError {
+	var lines []string
+	for _, line := range strings.FieldsFunc(string(output), func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+			lines = append(lines, sanitizeURL(line))
+		}
+	}
+	return &CommandError{Err: err, Output: strings.Join(lines, "; ")}
+}
+
+// Error 实现error
+func (e *CommandError) Error() string {
+	if e.Output == "" {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + ": " + e.Output
+}
+
+// Unwrap 返回底层错误
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// permanentPatterns 重试也不会成功的git错误输出（小写）
+var permanentPatterns = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"permission denied",
+	"access denied",
+	"repository not found",
+	"does not appear to be a git repository",
+	"couldn't find remote ref",
+	"invalid ref",
+	"not a valid object name",
+	"unknown revision",
+	"host key verification failed",
+}
+
+// retryablePatterns 网络抖动、超时、锁文件等临时失败的git错误输出（小写）
+var retryablePatterns = []string{
+	"could not resolve host",
+	"failed to connect",
+	"connection timed out",
+	"operation timed out",
+	"connection reset",
+	"connection refused",
+	"network is unreachable",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"rpc failed",
+	"unexpected disconnect",
+	"tls connection was non-properly terminated",
+	"gnutls_handshake() failed",
+	"ssl_read",
+	"http/2 stream",
+	"the requested url returned error: 5",
+	".lock': file exists",
+	"cannot lock ref",
+	"unable to create temporary file",
+}
+
+// IsRetryable 判断git操作失败是否为临时失败，未能识别的错误视为不可重试
+//
+// 先匹配认证失败、引用不存在等永久错误，再匹配网络错误、超时和锁文件。
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// go-git 返回类型化的错误
+	switch {
+	case errors.Is(err, ErrRefNotFound),
+		errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrRepositoryNotFound),
+		errors.Is(err, transport.ErrInvalidAuthMethod):
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range permanentPatterns {
+		if strings.Contains(msg, pattern) {
+			return false
+		}
+	}
+	for _, pattern := range retryablePatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
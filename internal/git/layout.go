@@ -0,0 +1,118 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+// unsafePathChars 工作空间路径中不允许的字符
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// scpLikeURL git@host:group/repo.git 形式的地址
+var scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// WorkspacePath 根据仓库地址生成缓存目录下的克隆路径
+//
+// 布局为 <cacheDir>/<host>/<path>-<hash>，hash取规范化URL的sha256前8位，
+// 不同主机或不同组下的同名仓库互不冲突，同一仓库的 https/ssh 地址也各自独立。
+func WorkspacePath(cacheDir, repoURL string) string {
+	host, path := splitRepoURL(repoURL)
+
+	segments := []string{cacheDir, sanitizeSegment(host)}
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		part = sanitizeSegment(part)
+		if i == len(parts)-1 {
+			part = part + "-" + shortHash(repoScheme(repoURL)+"://"+NormalizeRepoURL(repoURL))
+		}
+		segments = append(segments, part)
+	}
+
+	return filepath.Join(segments...)
+}
+
+// NormalizeRepoURL 规范化仓库地址：去掉凭据、末尾的 / 和 .git，主机名转小写
+func NormalizeRepoURL(repoURL string) string {
+	host, path := splitRepoURL(repoURL)
+	return host + "/" + path
+}
+
+// RemoteOriginURL 读取克隆目录中 origin 的地址
+func RemoteOriginURL(localPath string) (string, error) {
+	repo, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", fmt.Errorf("failed to get origin: %w", err)
+	}
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		return urls[0], nil
+	}
+
+	return "", errors.New("origin has no url")
+}
+
+// repoScheme 返回仓库地址的协议，git@host:path 形式视为ssh
+func repoScheme(repoURL string) string {
+	if i := strings.Index(repoURL, "://"); i > 0 {
+		return strings.ToLower(repoURL[:i])
+	}
+	return "ssh"
+}
+
+// splitRepoURL 拆分出主机（含非默认端口）和仓库路径
+func splitRepoURL(repoURL string) (host, path string) {
+	raw := strings.TrimSpace(repoURL)
+
+	if strings.Contains(raw, "://") {
+		if u, err := url.Parse(raw); err == nil {
+			host = u.Host
+			path = u.Path
+		}
+	} else if m := scpLikeURL.FindStringSubmatch(raw); m != nil {
+		host = m[1]
+		path = m[2]
+	} else {
+		path = raw
+	}
+
+	host = strings.ToLower(host)
+	if host == "" {
+		host = "local"
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	if path == "" {
+		path = "repo"
+	}
+
+	return host, path
+}
+
+// sanitizeSegment 将路径片段限制为安全字符，避免 .. 等目录穿越
+func sanitizeSegment(s string) string {
+	s = unsafePathChars.ReplaceAllString(s, "_")
+	s = strings.Trim(s, ".")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// shortHash 返回sha256前8位十六进制
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:8]
+}
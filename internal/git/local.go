@@ -0,0 +1,72 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ErrLocalRepoNotAllowed 本地仓库路径不在允许的根目录下
+var ErrLocalRepoNotAllowed = errors.New("local repository path is not under an allowed root")
+
+// IsLocalURL 判断仓库地址是否为 file:// URL 或本地绝对路径
+//
+// 本地仓库原地分析，不克隆也不拉取，由管理员通过 security.local_repo_roots 授权。
+func IsLocalURL(repoURL string) bool {
+	return strings.HasPrefix(repoURL, "file://") || filepath.IsAbs(repoURL)
+}
+
+// LocalPathFromURL 将 file:// URL 或本地路径转换为文件系统路径
+func LocalPathFromURL(repoURL string) (string, error) {
+	if !strings.HasPrefix(repoURL, "file://") {
+		return filepath.Clean(repoURL), nil
+	}
+
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid file url: %w", err)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file url must not have a remote host: %s", u.Host)
+	}
+	if u.Path == "" {
+		return "", errors.New("file url has no path")
+	}
+
+	return filepath.Clean(filepath.FromSlash(u.Path)), nil
+}
+
+// CheckLocalPath 解析本地仓库路径（含符号链接），并校验其位于允许的根目录下
+func CheckLocalPath(repoURL string, roots []string) (string, error) {
+	if len(roots) == 0 {
+		return "", fmt.Errorf("local repositories are disabled: %w", ErrLocalRepoNotAllowed)
+	}
+
+	path, err := LocalPathFromURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve local path: %w", err)
+	}
+
+	for _, root := range roots {
+		resolvedRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolvedRoot, resolved)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: %w", path, ErrLocalRepoNotAllowed)
+}
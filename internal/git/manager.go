@@ -6,13 +6,56 @@ import (
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
 )
 
+// MirrorFetchRefSpec 裸镜像的fetch规则：远程分支直接映射为本地分支
+const MirrorFetchRefSpec = "+refs/heads/*:refs/heads/*"
+
+// CloneOptions 克隆策略
+type CloneOptions struct {
+	Strategy     string // full/blobless/treeless/shallow，空表示full
+	ShallowSince string // Strategy=shallow 时的起始日期
+}
+
+// CloneOptionsFor 返回仓库的克隆策略
+func CloneOptionsFor(repo *models.Repository) CloneOptions {
+	return CloneOptions{
+		Strategy:     repo.CloneStrategy,
+		ShallowSince: repo.ShallowSince,
+	}
+}
+
+// cloneArgs 克隆策略对应的git clone参数
+func (o CloneOptions) cloneArgs() []string {
+	switch o.Strategy {
+	case models.CloneStrategyBlobless:
+		return []string{"--filter=blob:none"}
+	case models.CloneStrategyTreeless:
+		return []string{"--filter=tree:0"}
+	case models.CloneStrategyShallow:
+		return []string{"--shallow-since=" + o.ShallowSince}
+	}
+	return nil
+}
+
+// fetchArgs 克隆策略对应的git fetch/pull参数
+//
+// 部分克隆的过滤条件记录在仓库配置中，后续fetch自动沿用；浅克隆需要显式保持历史边界。
+func (o CloneOptions) fetchArgs() []string {
+	if o.Strategy == models.CloneStrategyShallow {
+		return []string{"--shallow-since=" + o.ShallowSince}
+	}
+	return nil
+}
+
 // Manager Git管理器接口
 type Manager interface {
-	// Clone 克隆仓库
-	Clone(ctx context.Context, url, localPath string, cred *models.Credential) error
+	// Clone 按克隆策略克隆仓库为裸镜像
+	Clone(ctx context.Context, url, localPath string, cred *models.Credential, opts CloneOptions) error
 
-	// Pull 拉取更新
-	Pull(ctx context.Context, localPath string, cred *models.Credential) error
+	// Pull 拉取更新（裸镜像为fetch），浅克隆保持原有历史边界
+	Pull(ctx context.Context, localPath string, cred *models.Credential, opts CloneOptions) error
+
+	// LsRemote 使用凭据列出远程引用，用于验证凭据能否访问仓库
+	LsRemote(ctx context.Context, url string, cred *models.Credential) error
 
 	// Checkout 切换分支
 	Checkout(ctx context.Context, localPath, branch string) error
@@ -23,12 +66,19 @@ type Manager interface {
 	// GetHeadCommitHash 获取HEAD commit hash
 	GetHeadCommitHash(ctx context.Context, localPath string) (string, error)
 
+	// ResolveRef 将分支、标签或远程引用解析为commit hash
+	ResolveRef(ctx context.Context, localPath, ref string) (string, error)
+
 	// CountCommits 统计提交次数
 	CountCommits(ctx context.Context, localPath, branch, fromDate string) (int, error)
 
 	// ListBranches 获取分支列表
 	ListBranches(ctx context.Context, localPath string) ([]string, error)
 
+	// ListRefs 列出本地分支、远程分支和标签及其指向的提交，refType为空时返回全部类型
+	// base 为默认分支的commit hash，非空时为最近的 MaxAheadBehindRefs 个引用计算相对它的领先/落后提交数
+	ListRefs(ctx context.Context, localPath, refType, base string) ([]models.Ref, error)
+
 	// IsAvailable 检查Git是否可用
 	IsAvailable() bool
 }
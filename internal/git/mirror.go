@@ -0,0 +1,106 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ConvertToBare 将旧版本的完整工作副本原地转换为裸镜像，已是裸仓库时返回false
+//
+// 工作区文件直接删除，.git 目录提升为仓库目录并配置分支镜像fetch规则；远程跟踪分支
+// refs/remotes/origin/* 改写为 refs/heads/*，与 Clone 得到的裸镜像保持一致。
+func ConvertToBare(localPath string) (bool, error) {
+	repo, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, err := repo.Worktree(); errors.Is(err, gogit.ErrIsBareRepository) {
+		return false, nil
+	}
+
+	gitDir := filepath.Join(localPath, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return false, fmt.Errorf("%s is not a git directory", gitDir)
+	}
+
+	// 先把 .git 移到旁边，删除工作区后再移回，中途失败时仓库数据仍保留在临时目录中
+	tmpDir := localPath + ".bare"
+	if err := os.Rename(gitDir, tmpDir); err != nil {
+		return false, fmt.Errorf("failed to move git directory: %w", err)
+	}
+	if err := os.RemoveAll(localPath); err != nil {
+		return false, fmt.Errorf("failed to remove working tree: %w", err)
+	}
+	if err := os.Rename(tmpDir, localPath); err != nil {
+		return false, fmt.Errorf("failed to move git directory: %w", err)
+	}
+	// 裸仓库不需要暂存区
+	if err := os.Remove(filepath.Join(localPath, "index")); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove index: %w", err)
+	}
+
+	repo, err = gogit.PlainOpen(localPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return false, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg.Core.IsBare = true
+	cfg.Core.Worktree = ""
+	if err := repo.SetConfig(cfg); err != nil {
+		return false, fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := setMirrorRefSpec(repo); err != nil {
+		return false, fmt.Errorf("failed to configure mirror refspec: %w", err)
+	}
+
+	if err := mirrorRemoteBranches(repo); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// mirrorRemoteBranches 把 refs/remotes/origin/* 改写为 refs/heads/* 并删除远程跟踪分支
+func mirrorRemoteBranches(repo *gogit.Repository) error {
+	refs, err := repo.References()
+	if err != nil {
+		return fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	remotes := make([]*plumbing.Reference, 0)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() {
+			remotes = append(remotes, ref)
+		}
+		return nil
+	})
+	refs.Close()
+	if err != nil {
+		return fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	for _, ref := range remotes {
+		branch := strings.TrimPrefix(ref.Name().String(), "refs/remotes/origin/")
+		if ref.Type() == plumbing.HashReference && branch != ref.Name().String() && branch != "HEAD" {
+			head := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), ref.Hash())
+			if err := repo.Storer.SetReference(head); err != nil {
+				return fmt.Errorf("failed to update branch %s: %w", branch, err)
+			}
+		}
+		if err := repo.Storer.RemoveReference(ref.Name()); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", ref.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// refsFormat for-each-ref 输出格式，字段以NUL分隔，带 * 的字段为附注标签解引用后的提交
+var refsFormat = strings.Join([]string{
+	"%(refname)",
+	"%(objecttype)",
+	"%(objectname)",
+	"%(committerdate:iso-strict)",
+	"%(authorname)",
+	"%(authoremail)",
+	"%(*objecttype)",
+	"%(*objectname)",
+	"%(*committerdate:iso-strict)",
+	"%(*authorname)",
+	"%(*authoremail)",
+}, "%00")
+
+// refsFieldCount refsFormat 的字段数
+const refsFieldCount = 11
+
+// MaxAheadBehindRefs 单次最多计算领先/落后的引用数
+//
+// 每个引用都要遍历一次历史，引用很多的大仓库只为最近的引用计算。
+const MaxAheadBehindRefs = 100
+
+// refPrefixes 引用前缀与类型
+var refPrefixes = []struct {
+	prefix  string
+	refType string
+}{
+	{"refs/heads/", models.RefTypeBranch},
+	{"refs/remotes/", models.RefTypeRemote},
+	{"refs/tags/", models.RefTypeTag},
+}
+
+// parseForEachRef 解析 for-each-ref 输出，跳过远程HEAD指针和不指向提交的标签
+func parseForEachRef(output string) []models.Ref {
+	refs := make([]models.Ref, 0)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != refsFieldCount {
+			continue
+		}
+
+		ref, ok := newRef(fields[0])
+		if !ok {
+			continue
+		}
+
+		// 附注标签取解引用后的提交
+		objType, sha, date, author, email := fields[1], fields[2], fields[3], fields[4], fields[5]
+		if objType == "tag" {
+			objType, sha, date, author, email = fields[6], fields[7], fields[8], fields[9], fields[10]
+		}
+		if objType != "commit" {
+			continue
+		}
+
+		ref.SHA = sha
+		ref.Author = author
+		ref.AuthorEmail = strings.Trim(email, "<>")
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			ref.Date = &t
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+// refNamespaces 返回引用类型对应的命名空间，refType为空时返回全部
+func refNamespaces(refType string) []string {
+	namespaces := make([]string, 0, len(refPrefixes))
+	for _, p := range refPrefixes {
+		if refType == "" || p.refType == refType {
+			namespaces = append(namespaces, strings.TrimSuffix(p.prefix, "/"))
+		}
+	}
+	return namespaces
+}
+
+// newRef 根据完整引用名创建Ref，不认识的引用返回false
+func newRef(fullName string) (models.Ref, bool) {
+	for _, p := range refPrefixes {
+		if !strings.HasPrefix(fullName, p.prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(fullName, p.prefix)
+		if p.refType == models.RefTypeRemote && strings.HasSuffix(name, "/HEAD") {
+			return models.Ref{}, false
+		}
+		return models.Ref{Name: name, FullName: fullName, Type: p.refType}, true
+	}
+	return models.Ref{}, false
+}
+
+// sortRefs 按提交时间从新到旧排序，时间相同按名称
+func sortRefs(refs []models.Ref) {
+	sort.SliceStable(refs, func(i, j int) bool {
+		di, dj := refs[i].Date, refs[j].Date
+		if di != nil && dj != nil && !di.Equal(*dj) {
+			return di.After(*dj)
+		}
+		if (di == nil) != (dj == nil) {
+			return di != nil
+		}
+		return refs[i].FullName < refs[j].FullName
+	})
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Commit 提交信息及其numstat摘要
+type Commit struct {
+	SHA          string       `json:"sha"`
+	Parents      []string     `json:"parents"`
+	Author       string       `json:"author"`
+	AuthorEmail  string       `json:"author_email"`
+	Date         time.Time    `json:"date"` // 作者时间，与统计使用的时间一致
+	Subject      string       `json:"subject"`
+	Additions    int          `json:"additions"`
+	Deletions    int          `json:"deletions"`
+	FilesChanged int          `json:"files_changed"`
+	Files        []CommitFile `json:"files"`
+}
+
+// CommitFile 提交中单个文件的变更行数
+type CommitFile struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"` // 二进制文件不计行数
+}
+
+// CommitDetail 单个提交的完整信息
+type CommitDetail struct {
+	Commit
+	Committer      string      `json:"committer"`
+	CommitterEmail string      `json:"committer_email"`
+	CommitDate     time.Time   `json:"commit_date"`
+	Message        string      `json:"message"`  // 完整提交说明
+	Trailers       []Trailer   `json:"trailers"` // 如 Signed-off-by、Co-authored-by
+	Diff           *CommitDiff `json:"diff,omitempty"`
+}
+
+// Trailer 提交说明末尾的键值对
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// CommitDiff 提交的unified diff，按文件分页
+type CommitDiff struct {
+	Files      []FileDiff `json:"files"`
+	Offset     int        `json:"offset"`                // 本页第一个文件的序号
+	NextOffset int        `json:"next_offset,omitempty"` // 下一页起始序号，has_more为true时有效
+	HasMore    bool       `json:"has_more"`
+}
+
+// FileDiff 单个文件的diff
+type FileDiff struct {
+	Path      string `json:"path"`
+	Patch     string `json:"patch"`
+	Truncated bool   `json:"truncated,omitempty"` // 超过大小上限被截断
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Ref 仓库引用（分支或标签）及其指向的提交
+type Ref struct {
+	Name        string     `json:"name"`      // 短名称，如 main、origin/dev、v1.0.0
+	FullName    string     `json:"full_name"` // 完整引用名，如 refs/heads/main
+	Type        string     `json:"type"`      // branch/remote/tag
+	SHA         string     `json:"sha"`       // 指向的提交（附注标签已解引用）
+	Date        *time.Time `json:"date,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	AuthorEmail string     `json:"author_email,omitempty"`
+	Ahead       *int       `json:"ahead,omitempty"`  // 相对默认分支领先的提交数
+	Behind      *int       `json:"behind,omitempty"` // 相对默认分支落后的提交数
+}
+
+// Ref Type constants
+const (
+	RefTypeBranch = "branch"
+	RefTypeRemote = "remote"
+	RefTypeTag    = "tag"
+)
@@ -8,12 +8,18 @@ type Repository struct {
 	URL            string     `json:"url" db:"url"`
 	Name           string     `json:"name" db:"name"`
 	CurrentBranch  string     `json:"current_branch" db:"current_branch"`
+	Labels         []string   `json:"labels" db:"labels"` // 仓库标签（JSON存储），用于跨仓库统计
 	LocalPath      string     `json:"local_path" db:"local_path"`
-	Status         string     `json:"status" db:"status"` // pending/cloning/ready/failed
+	CloneStrategy  string     `json:"clone_strategy" db:"clone_strategy"`         // full/blobless/treeless/shallow
+	ShallowSince   string     `json:"shallow_since,omitempty" db:"shallow_since"` // clone_strategy=shallow 时的起始日期
+	Status         string     `json:"status" db:"status"`                         // pending/cloning/ready/failed
 	ErrorMessage   *string    `json:"error_message,omitempty" db:"error_message"`
 	LastPullAt     *time.Time `json:"last_pull_at,omitempty" db:"last_pull_at"`
 	LastCommitHash *string    `json:"last_commit_hash,omitempty" db:"last_commit_hash"`
-	CredentialID   *string    `json:"-" db:"credential_id"` // 不返回给前端
+	SyncInterval   *int       `json:"sync_interval_minutes,omitempty" db:"sync_interval_minutes"` // 自动同步间隔（分钟），为空使用全局配置，0表示不自动同步
+	LastSyncAt     *time.Time `json:"last_sync_at,omitempty" db:"last_sync_at"`                   // 上次自动同步提交pull任务的时间
+	NextSyncAt     *time.Time `json:"next_sync_at,omitempty" db:"next_sync_at"`                   // 下次自动同步时间
+	CredentialID   *string    `json:"-" db:"credential_id"`                                       // 不返回给前端
 	HasCredentials bool       `json:"has_credentials" db:"-"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
@@ -26,3 +32,11 @@ const (
 	RepoStatusReady   = "ready"
 	RepoStatusFailed  = "failed"
 )
+
+// Clone Strategy constants
+const (
+	CloneStrategyFull     = "full"
+	CloneStrategyBlobless = "blobless" // --filter=blob:none，文件内容按需拉取
+	CloneStrategyTreeless = "treeless" // --filter=tree:0，目录树和文件内容按需拉取
+	CloneStrategyShallow  = "shallow"  // --shallow-since，只保留指定日期之后的历史
+)
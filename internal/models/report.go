@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Report 定时报表定义
+type Report struct {
+	ID         int64            `json:"id" db:"id"`
+	Name       string           `json:"name" db:"name"`
+	RepoIDs    []int64          `json:"repo_ids,omitempty" db:"repo_ids"` // JSON存储，与label二选一
+	Label      string           `json:"label,omitempty" db:"label"`       // 按标签选择仓库，运行时解析
+	Branch     string           `json:"branch,omitempty" db:"branch"`     // 为空时使用各仓库当前分支
+	Constraint *StatsConstraint `json:"constraint,omitempty" db:"constraint"`
+	Relative   string           `json:"relative,omitempty" db:"relative"` // 相对时间范围，与constraint二选一，运行时解析为日期范围
+	Cron       string           `json:"cron" db:"cron"`                   // 5段cron表达式，按服务器时区
+	Enabled    bool             `json:"enabled" db:"enabled"`
+	LastRunAt  *time.Time       `json:"last_run_at,omitempty" db:"last_run_at"`
+	NextRunAt  *time.Time       `json:"next_run_at,omitempty" db:"next_run_at"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// ReportRun 报表的一次运行
+type ReportRun struct {
+	ID           int64            `json:"id" db:"id"`
+	ReportID     int64            `json:"report_id" db:"report_id"`
+	Trigger      string           `json:"trigger" db:"trigger"` // schedule/manual
+	Status       string           `json:"status" db:"status"`   // running/completed/failed
+	Constraint   *StatsConstraint `json:"constraint" db:"constraint"`
+	AggregateKey string           `json:"aggregate_key,omitempty" db:"aggregate_key"`
+	Repos        []AggregateRepo  `json:"repos,omitempty" db:"repos"` // 各仓库的统计缓存键（JSON存储）
+	Error        string           `json:"error,omitempty" db:"error"`
+	StartedAt    time.Time        `json:"started_at" db:"started_at"`
+	CompletedAt  *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Report Run Trigger constants
+const (
+	ReportTriggerSchedule = "schedule"
+	ReportTriggerManual   = "manual"
+)
+
+// Relative Range constants
+const (
+	RelativeLast7Days       = "last_7_days"      // 截至昨天的7天
+	RelativeLast30Days      = "last_30_days"     // 截至昨天的30天
+	RelativeWeekToDate      = "week_to_date"     // 本周一至今天
+	RelativeMonthToDate     = "month_to_date"    // 本月1日至今天
+	RelativePreviousWeek    = "previous_week"    // 上周一至上周日
+	RelativePreviousMonth   = "previous_month"   // 上个自然月
+	RelativePreviousQuarter = "previous_quarter" // 上个自然季度
+	RelativePreviousYear    = "previous_year"    // 上个自然年
+)
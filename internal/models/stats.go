@@ -10,6 +10,7 @@ type StatsCache struct {
 	ConstraintType  string     `json:"constraint_type" db:"constraint_type"`   // date_range/commit_limit
 	ConstraintValue string     `json:"constraint_value" db:"constraint_value"` // JSON string
 	CommitHash      string     `json:"commit_hash" db:"commit_hash"`
+	TeamsVersion    string     `json:"teams_version" db:"teams_version"` // 团队映射版本
 	ResultPath      string     `json:"result_path" db:"result_path"`
 	ResultSize      int64      `json:"result_size" db:"result_size"`
 	CacheKey        string     `json:"cache_key" db:"cache_key"`
@@ -40,10 +41,28 @@ type StatsResult struct {
 	Statistics *Statistics `json:"statistics"`
 }
 
+// AggregateResult 跨仓库聚合统计结果
+type AggregateResult struct {
+	CacheHit   bool            `json:"cache_hit"`
+	CachedAt   *time.Time      `json:"cached_at,omitempty"`
+	Repos      []AggregateRepo `json:"repos"`
+	Statistics *Statistics     `json:"statistics"`
+}
+
+// AggregateRepo 参与聚合的仓库
+type AggregateRepo struct {
+	RepoID     int64  `json:"repo_id"`
+	Name       string `json:"name"`
+	Branch     string `json:"branch"`
+	CommitHash string `json:"commit_hash"`
+	CacheKey   string `json:"cache_key"`
+}
+
 // Statistics 统计数据
 type Statistics struct {
 	Summary       StatsSummary       `json:"summary"`
 	ByContributor []ContributorStats `json:"by_contributor"`
+	ByTeam        []TeamStats        `json:"by_team"`
 }
 
 // StatsSummary 统计摘要
@@ -73,11 +92,67 @@ type ContributorStats struct {
 	LastCommitDate  string `json:"last_commit_date"`  // 最后提交日期
 }
 
+// TeamStats 团队统计
+type TeamStats struct {
+	Team          string   `json:"team"`
+	Members       []string `json:"members"` // 参与统计的成员邮箱
+	Contributors  int      `json:"contributors"`
+	Commits       int      `json:"commits"`
+	Additions     int      `json:"additions"`
+	Deletions     int      `json:"deletions"`
+	Modifications int      `json:"modifications"`
+	NetAdditions  int      `json:"net_additions"`
+}
+
+// StatsComparison 两个统计周期的对比
+type StatsComparison struct {
+	Commits              MetricDelta        `json:"commits"`
+	Contributors         MetricDelta        `json:"contributors"`
+	ByContributor        []ContributorDelta `json:"by_contributor"`
+	NewContributors      []string           `json:"new_contributors"`      // 仅出现在对比周期的邮箱
+	DepartedContributors []string           `json:"departed_contributors"` // 仅出现在基准周期的邮箱
+}
+
+// MetricDelta 单项指标变化
+type MetricDelta struct {
+	Base          int      `json:"base"`
+	Target        int      `json:"target"`
+	Delta         int      `json:"delta"`                    // target - base
+	ChangePercent *float64 `json:"change_percent,omitempty"` // 基准为0时不计算
+}
+
+// ContributorDelta 贡献者指标变化
+type ContributorDelta struct {
+	Author        string      `json:"author"`
+	Email         string      `json:"email"`
+	Status        string      `json:"status"` // new/departed/retained
+	Commits       MetricDelta `json:"commits"`
+	Additions     MetricDelta `json:"additions"`
+	Deletions     MetricDelta `json:"deletions"`
+	Modifications MetricDelta `json:"modifications"`
+	NetAdditions  MetricDelta `json:"net_additions"`
+}
+
+// Contributor Delta Status constants
+const (
+	ContributorStatusNew      = "new"
+	ContributorStatusDeparted = "departed"
+	ContributorStatusRetained = "retained"
+)
+
 // Credential 凭据模型
+//
+// 密码、令牌、私钥等敏感字段不参与JSON序列化，API只返回凭据的元信息。
 type Credential struct {
 	ID            string    `json:"id" db:"id"`
-	Username      string    `json:"username,omitempty" db:"-"` // 不直接存储，存在EncryptedData中
-	Password      string    `json:"password,omitempty" db:"-"` // 不直接存储
+	Name          string    `json:"name" db:"name"`
+	Username      string    `json:"username,omitempty" db:"-"`        // 不直接存储，存在EncryptedData中
+	Password      string    `json:"-" db:"-"`                         // 不直接存储；auth_type=token 时为访问令牌
+	PrivateKey    string    `json:"-" db:"-"`                         // SSH私钥（PEM），不直接存储
+	Passphrase    string    `json:"-" db:"-"`                         // SSH私钥口令，不直接存储
+	KnownHosts    string    `json:"known_hosts,omitempty" db:"-"`     // SSH主机公钥（known_hosts格式），可选
+	Host          string    `json:"host" db:"-"`                      // 绑定的主机，只能用于该主机上的仓库，存在EncryptedData中以防篡改
+	TrustNewHosts bool      `json:"trust_new_hosts,omitempty" db:"-"` // 未提供known_hosts时首次连接信任未知主机（TOFU），默认只信任系统known_hosts
 	AuthType      string    `json:"auth_type" db:"auth_type"`
 	EncryptedData []byte    `json:"-" db:"encrypted_data"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
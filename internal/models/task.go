@@ -4,20 +4,39 @@ import "time"
 
 // Task 任务模型
 type Task struct {
-	ID           int64      `json:"id" db:"id"`
-	TaskType     string     `json:"task_type" db:"task_type"`
-	RepoID       int64      `json:"repo_id" db:"repo_id"`
-	Status       string     `json:"status" db:"status"`
-	Priority     int        `json:"priority" db:"priority"`
-	Parameters   string     `json:"parameters,omitempty" db:"parameters"`   // JSON string
-	Result       *string    `json:"result,omitempty" db:"result"`           // JSON string
-	ErrorMessage *string    `json:"error_message,omitempty" db:"error_message"`
-	RetryCount   int        `json:"retry_count" db:"retry_count"`
-	StartedAt    *time.Time `json:"started_at,omitempty" db:"started_at"`
-	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
-	DurationMs   *int64     `json:"duration_ms,omitempty" db:"-"` // 计算字段
+	ID           int64         `json:"id" db:"id"`
+	TaskType     string        `json:"task_type" db:"task_type"`
+	RepoID       int64         `json:"repo_id" db:"repo_id"`
+	Status       string        `json:"status" db:"status"`
+	Priority     int           `json:"priority" db:"priority"`
+	Parameters   string        `json:"parameters,omitempty" db:"parameters"` // JSON string
+	Result       *string       `json:"result,omitempty" db:"result"`         // JSON string
+	ErrorMessage *string       `json:"error_message,omitempty" db:"error_message"`
+	RetryCount   int           `json:"retry_count" db:"retry_count"`
+	Attempts     []TaskAttempt `json:"attempts,omitempty" db:"attempts"` // JSON string，每次失败执行的记录
+	Progress     *TaskProgress `json:"progress,omitempty" db:"progress"` // JSON string，运行中的进度
+	StartedAt    *time.Time    `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt  *time.Time    `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+	DurationMs   *int64        `json:"duration_ms,omitempty" db:"-"` // 计算字段
+}
+
+// TaskProgress 任务执行进度，Percent为当前阶段的完成百分比
+type TaskProgress struct {
+	Phase     string    `json:"phase"`   // 如 receiving_objects、resolving_deltas、reading_log
+	Percent   int       `json:"percent"` // 0-100
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskAttempt 一次失败的执行记录
+type TaskAttempt struct {
+	Attempt    int       `json:"attempt"` // 从1开始
+	Error      string    `json:"error"`
+	Retryable  bool      `json:"retryable"` // 是否判定为临时失败
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
 }
 
 // Task Type constants
@@ -41,8 +60,8 @@ const (
 
 // TaskParameters 任务参数结构
 type TaskParameters struct {
-	Branch     string              `json:"branch,omitempty"`
-	Constraint *StatsConstraint    `json:"constraint,omitempty"`
+	Branch     string           `json:"branch,omitempty"`
+	Constraint *StatsConstraint `json:"constraint,omitempty"`
 }
 
 // TaskResult 任务结果结构
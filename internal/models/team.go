@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Team 团队模型
+type Team struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Emails    []string  `json:"emails" db:"emails"`     // 精确匹配的邮箱列表（JSON存储）
+	Patterns  []string  `json:"patterns" db:"patterns"` // 邮箱正则列表（JSON存储）
+	Source    string    `json:"source" db:"-"`          // config/api
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Team Source constants
+const (
+	TeamSourceConfig = "config"
+	TeamSourceAPI    = "api"
+)
+
+// TeamUnassigned 未归属任何团队的贡献者所在分组
+const TeamUnassigned = "unassigned"
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// WebhookSubscription 事件订阅，事件发生时向URL投递签名的JSON
+type WebhookSubscription struct {
+	ID          int64     `json:"id" db:"id"`
+	URL         string    `json:"url" db:"url"`
+	Events      []string  `json:"events" db:"events"` // 订阅的事件（JSON存储），"*" 表示全部
+	Secret      string    `json:"-" db:"secret"`      // 签名密钥，只在创建时返回
+	Description string    `json:"description,omitempty" db:"description"`
+	Enabled     bool      `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery 一次事件投递及其重试状态
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	SubscriptionID int64      `json:"subscription_id" db:"subscription_id"`
+	EventID        string     `json:"event_id" db:"event_id"` // 同一事件投递给各订阅时相同，接收方可据此去重
+	Event          string     `json:"event" db:"event"`
+	Payload        string     `json:"payload" db:"payload"` // JSON string
+	Status         string     `json:"status" db:"status"`   // pending/success/failed
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseCode   int        `json:"response_code,omitempty" db:"response_code"` // 最近一次响应的HTTP状态码
+	Error          string     `json:"error,omitempty" db:"error"`                 // 最近一次失败原因
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookEvent 投递的请求体
+type WebhookEvent struct {
+	ID         string      `json:"id"`
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Webhook Event constants
+const (
+	EventAll           = "*"
+	EventPing          = "ping" // 测试投递，不能订阅
+	EventTaskCompleted = "task.completed"
+	EventTaskFailed    = "task.failed"
+	EventRepoReady     = "repo.ready"
+	EventRepoFailed    = "repo.failed"
+	EventStatsReady    = "stats.ready"
+)
+
+// WebhookEvents 可订阅的事件
+var WebhookEvents = []string{
+	EventTaskCompleted,
+	EventTaskFailed,
+	EventRepoReady,
+	EventRepoFailed,
+	EventStatsReady,
+}
+
+// Webhook Delivery Status constants
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+)
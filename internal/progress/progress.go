@@ -0,0 +1,93 @@
+// Package progress 通过context在任务执行链路中上报进度
+//
+// worker为每个任务放入回调，git操作和统计计算在执行过程中上报，未设置回调时上报被忽略。
+package progress
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Progress 进度快照，Percent为当前阶段的完成百分比
+type Progress struct {
+	Phase   string
+	Percent int
+	Message string
+}
+
+// Func 进度回调，可能在执行git命令的goroutine中调用
+type Func func(p Progress)
+
+type ctxKey struct{}
+
+// WithReporter 返回携带进度回调的context
+func WithReporter(ctx context.Context, fn Func) context.Context {
+	return context.WithValue(ctx, ctxKey{}, fn)
+}
+
+// Enabled context中是否设置了进度回调
+func Enabled(ctx context.Context) bool {
+	_, ok := ctx.Value(ctxKey{}).(Func)
+	return ok
+}
+
+// Report 上报进度，未设置回调时忽略
+func Report(ctx context.Context, phase string, percent int, message string) {
+	if fn, ok := ctx.Value(ctxKey{}).(Func); ok {
+		fn(Progress{Phase: phase, Percent: percent, Message: message})
+	}
+}
+
+// gitProgressPattern git --progress 的输出行，如 "Receiving objects:  45% (450/1000), 1.20 MiB | 2.00 MiB/s"
+var gitProgressPattern = regexp.MustCompile(`^(?:remote:\s*)?([A-Za-z][A-Za-z ]*?):\s+(\d+)%`)
+
+// GitWriter 返回解析 git --progress 输出并上报的Writer，未设置回调时返回nil
+//
+// git使用 \r 刷新同一行，按 \r 和 \n 切分。go-git的sideband进度输出格式相同。
+func GitWriter(ctx context.Context) io.Writer {
+	if !Enabled(ctx) {
+		return nil
+	}
+	return &gitWriter{ctx: ctx}
+}
+
+// gitWriter 解析git进度输出
+type gitWriter struct {
+	ctx  context.Context
+	mu   sync.Mutex
+	line []byte
+}
+
+// Write 实现io.Writer
+func (w *gitWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, b := range p {
+		if b == '\r' || b == '\n' {
+			w.flush()
+			continue
+		}
+		w.line = append(w.line, b)
+	}
+	return len(p), nil
+}
+
+// flush 解析一行进度
+func (w *gitWriter) flush() {
+	line := strings.TrimSpace(string(w.line))
+	w.line = w.line[:0]
+
+	match := gitProgressPattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+
+	percent, _ := strconv.Atoi(match[2])
+	phase := strings.ReplaceAll(strings.ToLower(match[1]), " ", "_")
+	Report(w.ctx, phase, percent, line)
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// loop 周期执行检查函数的后台循环，启动时立即执行一次
+type loop struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// start 启动循环
+func (l *loop) start(interval time.Duration, fn func(ctx context.Context, now time.Time)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			fn(ctx, time.Now())
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// stop 停止循环并等待当前检查结束
+func (l *loop) stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.wg.Wait()
+}
@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/service"
+)
+
+// ReportScheduler 定时报表调度器，定期运行到期的报表
+type ReportScheduler struct {
+	reportService *service.ReportService
+	checkInterval time.Duration
+	loop          loop
+}
+
+// NewReportScheduler 创建定时报表调度器
+func NewReportScheduler(reportService *service.ReportService, checkInterval time.Duration) *ReportScheduler {
+	return &ReportScheduler{
+		reportService: reportService,
+		checkInterval: checkInterval,
+	}
+}
+
+// Start 启动调度器
+func (s *ReportScheduler) Start() {
+	logger.Logger.Info().Dur("check_interval", s.checkInterval).Msg("starting report scheduler")
+
+	s.loop.start(s.checkInterval, s.reportService.RunDue)
+}
+
+// Stop 停止调度器
+func (s *ReportScheduler) Stop() {
+	s.loop.stop()
+
+	logger.Logger.Info().Msg("report scheduler stopped")
+}
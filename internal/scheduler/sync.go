@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
+)
+
+// SyncScheduler 仓库自动同步调度器，定期为到期的ready仓库提交pull任务
+//
+// 每个仓库的下次同步时间保存在 NextSyncAt 中，重启后按原计划继续；
+// NextSyncAt 为空（新仓库或修改了同步间隔）时在下一次检查中重新计算。
+type SyncScheduler struct {
+	store         storage.Store
+	queue         *worker.Queue
+	cfg           config.SyncConfig
+	checkInterval time.Duration
+	rand          *rand.Rand
+	loop          loop
+}
+
+// NewSyncScheduler 创建自动同步调度器
+func NewSyncScheduler(store storage.Store, queue *worker.Queue, cfg config.SyncConfig, checkInterval time.Duration) *SyncScheduler {
+	return &SyncScheduler{
+		store:         store,
+		queue:         queue,
+		cfg:           cfg,
+		checkInterval: checkInterval,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Start 启动调度器
+func (s *SyncScheduler) Start() {
+	logger.Logger.Info().
+		Dur("interval", s.cfg.Interval).
+		Dur("jitter", s.cfg.Jitter).
+		Dur("skip_if_pulled_within", s.cfg.SkipIfPulledWithin).
+		Msg("starting sync scheduler")
+
+	s.loop.start(s.checkInterval, s.RunOnce)
+}
+
+// Stop 停止调度器
+func (s *SyncScheduler) Stop() {
+	s.loop.stop()
+
+	logger.Logger.Info().Msg("sync scheduler stopped")
+}
+
+// RunOnce 检查所有ready仓库，为到期的仓库提交pull任务
+func (s *SyncScheduler) RunOnce(ctx context.Context, now time.Time) {
+	repos, err := listRepos(ctx, s.store, models.RepoStatusReady)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list repositories for sync")
+		return
+	}
+
+	for _, repo := range repos {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.syncRepo(ctx, repo, now); err != nil {
+			logger.Logger.Error().Err(err).Int64("repo_id", repo.ID).Msg("failed to schedule repository sync")
+		}
+	}
+}
+
+// syncRepo 处理单个仓库
+func (s *SyncScheduler) syncRepo(ctx context.Context, repo *models.Repository, now time.Time) error {
+	interval := s.interval(repo)
+	if interval <= 0 {
+		// 关闭了自动同步，清除计划时间
+		if repo.NextSyncAt == nil {
+			return nil
+		}
+		return s.updateSyncTimes(ctx, repo.ID, repo.LastSyncAt, nil)
+	}
+
+	if repo.NextSyncAt == nil {
+		// 从上次同步或拉取时间起算，长期未更新的仓库会在下一次检查时立即同步
+		base := now
+		if repo.LastSyncAt != nil {
+			base = *repo.LastSyncAt
+		} else if repo.LastPullAt != nil {
+			base = *repo.LastPullAt
+		}
+		next := s.nextRun(base, interval)
+		if err := s.updateSyncTimes(ctx, repo.ID, repo.LastSyncAt, &next); err != nil {
+			return err
+		}
+		repo.NextSyncAt = &next
+	}
+
+	if now.Before(*repo.NextSyncAt) {
+		return nil
+	}
+
+	if s.cfg.SkipIfPulledWithin > 0 && repo.LastPullAt != nil && now.Sub(*repo.LastPullAt) < s.cfg.SkipIfPulledWithin {
+		next := s.nextRun(*repo.LastPullAt, interval)
+		if !next.After(now) {
+			next = s.nextRun(now, interval)
+		}
+
+		logger.Logger.Debug().
+			Int64("repo_id", repo.ID).
+			Time("last_pull_at", *repo.LastPullAt).
+			Time("next_sync_at", next).
+			Msg("repository pulled recently, skipping sync")
+
+		return s.updateSyncTimes(ctx, repo.ID, repo.LastSyncAt, &next)
+	}
+
+	// 先记录同步时间再提交任务，避免pull任务写回仓库时覆盖新的计划时间
+	next := s.nextRun(now, interval)
+	if err := s.updateSyncTimes(ctx, repo.ID, &now, &next); err != nil {
+		return err
+	}
+
+	task := &models.Task{
+		TaskType: models.TaskTypePull,
+		RepoID:   repo.ID,
+		Priority: 0,
+	}
+	if err := s.queue.Enqueue(ctx, task); err != nil {
+		return fmt.Errorf("failed to enqueue pull task: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("repo_id", repo.ID).
+		Int64("task_id", task.ID).
+		Time("next_sync_at", next).
+		Msg("scheduled sync task submitted")
+
+	return nil
+}
+
+// interval 仓库的同步间隔，未单独设置时使用全局配置
+func (s *SyncScheduler) interval(repo *models.Repository) time.Duration {
+	if repo.SyncInterval != nil {
+		return time.Duration(*repo.SyncInterval) * time.Minute
+	}
+	return s.cfg.Interval
+}
+
+// nextRun 计算下次同步时间，加上随机抖动
+func (s *SyncScheduler) nextRun(base time.Time, interval time.Duration) time.Time {
+	next := base.Add(interval)
+	if s.cfg.Jitter > 0 {
+		next = next.Add(time.Duration(s.rand.Int63n(int64(s.cfg.Jitter))))
+	}
+	return next
+}
+
+// updateSyncTimes 重新读取仓库后只更新同步时间，减少覆盖其他并发修改的可能
+func (s *SyncScheduler) updateSyncTimes(ctx context.Context, repoID int64, last, next *time.Time) error {
+	repo, err := s.store.Repos().GetByID(ctx, repoID)
+	if err != nil {
+		return fmt.Errorf("failed to get repository: %w", err)
+	}
+	if repo == nil {
+		return nil
+	}
+
+	repo.LastSyncAt = last
+	repo.NextSyncAt = next
+	if err := s.store.Repos().Update(ctx, repo); err != nil {
+		return fmt.Errorf("failed to update repository: %w", err)
+	}
+	return nil
+}
+
+// listRepos 分页读取指定状态的全部仓库
+func listRepos(ctx context.Context, store storage.Store, status string) ([]*models.Repository, error) {
+	const pageSize = 100
+
+	all := make([]*models.Repository, 0)
+	for page := 1; ; page++ {
+		repos, total, err := store.Repos().List(ctx, status, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if len(repos) < pageSize || len(all) >= total {
+			break
+		}
+	}
+
+	return all, nil
+}
@@ -0,0 +1,171 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"golang.org/x/crypto/hkdf"
+)
+
+// MinKeyLength 加密密钥最小长度
+const MinKeyLength = 16
+
+// formatV1 密文格式版本：1字节版本号 + 12字节nonce + AES-GCM密文
+const formatV1 byte = 1
+
+// 标准AES-GCM的nonce和认证标签长度
+const (
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+// hkdfInfo 密钥派生上下文，区分同一配置密钥的其他用途
+const hkdfInfo = "gitcodestatic credentials v1"
+
+var (
+	// ErrNoEncryptionKey 未配置加密密钥
+	ErrNoEncryptionKey = errors.New("encryption key is not configured")
+	// ErrDecrypt 解密失败，通常是密钥错误
+	ErrDecrypt = errors.New("failed to decrypt credential, wrong encryption key?")
+)
+
+// payload 加密存储的凭据字段
+type payload struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	PrivateKey    string `json:"private_key,omitempty"`
+	Passphrase    string `json:"passphrase,omitempty"`
+	KnownHosts    string `json:"known_hosts,omitempty"`
+	Host          string `json:"host,omitempty"`
+	TrustNewHosts bool   `json:"trust_new_hosts,omitempty"`
+}
+
+// Cipher 凭据加解密器（AES-256-GCM）
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher 由配置的密钥通过HKDF-SHA256派生AES-256密钥
+func NewCipher(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, ErrNoEncryptionKey
+	}
+	if len(key) < MinKeyLength {
+		return nil, fmt.Errorf("encryption key must be at least %d characters", MinKeyLength)
+	}
+
+	derived := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(key), nil, []byte(hkdfInfo)), derived); err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal 加密凭据的敏感字段，返回写入EncryptedData的密文
+//
+// 凭据ID作为附加数据参与认证，密文不能挪用到其他凭据上。
+func (c *Cipher) Seal(cred *models.Credential) ([]byte, error) {
+	plaintext, err := json.Marshal(payload{
+		Username:      cred.Username,
+		Password:      cred.Password,
+		PrivateKey:    cred.PrivateKey,
+		Passphrase:    cred.Passphrase,
+		KnownHosts:    cred.KnownHosts,
+		Host:          cred.Host,
+		TrustNewHosts: cred.TrustNewHosts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal credential: %w", err)
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data := make([]byte, 0, 1+len(nonce)+len(plaintext)+c.aead.Overhead())
+	data = append(data, formatV1)
+	data = append(data, nonce...)
+
+	return c.aead.Seal(data, nonce, plaintext, []byte(cred.ID)), nil
+}
+
+// Open 解密EncryptedData，填充凭据的敏感字段
+func (c *Cipher) Open(cred *models.Credential) error {
+	data := cred.EncryptedData
+	nonceSize := c.aead.NonceSize()
+	if len(data) < 1+nonceSize || data[0] != formatV1 {
+		return fmt.Errorf("credential %s: unsupported encrypted data format", cred.ID)
+	}
+
+	plaintext, err := c.aead.Open(nil, data[1:1+nonceSize], data[1+nonceSize:], []byte(cred.ID))
+	if err != nil {
+		return fmt.Errorf("credential %s: %w", cred.ID, ErrDecrypt)
+	}
+
+	var p payload
+	if err := json.Unmarshal(plaintext, &p); err != nil {
+		return fmt.Errorf("credential %s: failed to unmarshal: %w", cred.ID, err)
+	}
+
+	cred.Username = p.Username
+	cred.Password = p.Password
+	cred.PrivateKey = p.PrivateKey
+	cred.Passphrase = p.Passphrase
+	cred.KnownHosts = p.KnownHosts
+	cred.Host = p.Host
+	cred.TrustNewHosts = p.TrustNewHosts
+
+	return nil
+}
+
+// IsEncrypted 判断EncryptedData是否为当前格式的密文
+//
+// 只检查版本号和长度，能否解密以 Open 的GCM认证为准。
+func IsEncrypted(cred *models.Credential) bool {
+	data := cred.EncryptedData
+	return len(data) >= 1+gcmNonceSize+gcmTagSize && data[0] == formatV1
+}
+
+// IsLegacyPlaintext 判断是否为旧版本保存的明文凭据
+//
+// 旧版本把凭据JSON明文直接写入EncryptedData，JSON以 '{' 开头，不会与版本号混淆。
+func IsLegacyPlaintext(cred *models.Credential) bool {
+	return len(cred.EncryptedData) > 0 && !IsEncrypted(cred)
+}
+
+// OpenLegacy 解析旧版本的明文EncryptedData，填充凭据的敏感字段
+func OpenLegacy(cred *models.Credential) error {
+	var p payload
+	if err := json.Unmarshal(cred.EncryptedData, &p); err != nil {
+		return fmt.Errorf("credential %s: unrecognized legacy credential format: %w", cred.ID, err)
+	}
+
+	cred.Username = p.Username
+	cred.Password = p.Password
+	cred.PrivateKey = p.PrivateKey
+	cred.Passphrase = p.Passphrase
+	cred.KnownHosts = p.KnownHosts
+	cred.Host = p.Host
+	cred.TrustNewHosts = p.TrustNewHosts
+
+	return nil
+}
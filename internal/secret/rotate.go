@@ -0,0 +1,110 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+// Rotate 使用新密钥重新加密所有凭据
+//
+// 先解密全部凭据，任何一条失败都不会写入；旧版本的明文凭据同样会被加密。
+// 已经是新密钥加密的凭据也能解密，中途失败后可用相同参数重新执行。
+// oldCipher 为nil时只能处理未加密或已用新密钥加密的凭据。返回重新加密的凭据数。
+func Rotate(ctx context.Context, store storage.CredentialStore, oldCipher, newCipher *Cipher) (int, error) {
+	creds, err := store.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	plain := make([]*models.Credential, 0, len(creds))
+	for _, cred := range creds {
+		if err := openAny(cred, oldCipher, newCipher); err != nil {
+			return 0, err
+		}
+		plain = append(plain, cred)
+	}
+
+	for i, cred := range plain {
+		if err := reseal(ctx, store, cred, newCipher); err != nil {
+			return i, err
+		}
+	}
+
+	return len(plain), nil
+}
+
+// MigrateLegacy 加密旧版本保存的明文凭据，已加密的凭据保持不变。返回加密的凭据数。
+func MigrateLegacy(ctx context.Context, store storage.CredentialStore, c *Cipher) (int, error) {
+	creds, err := store.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	migrated := 0
+	for _, cred := range creds {
+		if !IsLegacyPlaintext(cred) {
+			continue
+		}
+		if err := OpenLegacy(cred); err != nil {
+			return migrated, err
+		}
+		if err := reseal(ctx, store, cred, c); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// reseal 用指定密钥加密凭据并写回存储
+func reseal(ctx context.Context, store storage.CredentialStore, cred *models.Credential, c *Cipher) error {
+	data, err := c.Seal(cred)
+	if err != nil {
+		return err
+	}
+	sealed := stripSecrets(cred)
+	sealed.EncryptedData = data
+	if err := store.Update(ctx, sealed); err != nil {
+		return fmt.Errorf("failed to update credential %s: %w", cred.ID, err)
+	}
+	return nil
+}
+
+// Count 统计已保存的凭据数及其中旧版本明文凭据的数量
+func Count(ctx context.Context, store storage.CredentialStore) (total, unencrypted int, err error) {
+	creds, err := store.List(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	for _, cred := range creds {
+		if IsLegacyPlaintext(cred) {
+			unencrypted++
+		}
+	}
+	return len(creds), unencrypted, nil
+}
+
+// openAny 解密凭据：密文依次尝试各密钥，旧版本明文直接解析，空数据无需处理
+func openAny(cred *models.Credential, ciphers ...*Cipher) error {
+	if IsLegacyPlaintext(cred) {
+		return OpenLegacy(cred)
+	}
+	if !IsEncrypted(cred) {
+		return nil
+	}
+
+	err := fmt.Errorf("credential %s: %w", cred.ID, ErrNoEncryptionKey)
+	for _, c := range ciphers {
+		if c == nil {
+			continue
+		}
+		if err = c.Open(cred); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package secret
+
+import (
+	"context"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+// Store 在存储层之上透明加解密凭据
+type Store struct {
+	storage.Store
+	credentials *credentialStore
+}
+
+// NewStore 包装存储，cipher为nil时读写凭据均返回 ErrNoEncryptionKey
+func NewStore(store storage.Store, c *Cipher) *Store {
+	return &Store{
+		Store: store,
+		credentials: &credentialStore{
+			inner:  store.Credentials(),
+			cipher: c,
+		},
+	}
+}
+
+// Credentials 返回加密凭据存储
+func (s *Store) Credentials() storage.CredentialStore {
+	return s.credentials
+}
+
+// credentialStore 加密凭据存储，敏感字段只以密文形式传给底层存储
+type credentialStore struct {
+	inner  storage.CredentialStore
+	cipher *Cipher
+}
+
+func (s *credentialStore) Create(ctx context.Context, cred *models.Credential) error {
+	sealed, err := s.seal(cred)
+	if err != nil {
+		return err
+	}
+	if err := s.inner.Create(ctx, sealed); err != nil {
+		return err
+	}
+	copyMeta(cred, sealed)
+	return nil
+}
+
+func (s *credentialStore) GetByID(ctx context.Context, id string) (*models.Credential, error) {
+	cred, err := s.inner.GetByID(ctx, id)
+	if err != nil || cred == nil {
+		return cred, err
+	}
+	if err := s.open(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+func (s *credentialStore) List(ctx context.Context) ([]*models.Credential, error) {
+	creds, err := s.inner.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cred := range creds {
+		if err := s.open(cred); err != nil {
+			return nil, err
+		}
+	}
+	return creds, nil
+}
+
+func (s *credentialStore) Update(ctx context.Context, cred *models.Credential) error {
+	sealed, err := s.seal(cred)
+	if err != nil {
+		return err
+	}
+	if err := s.inner.Update(ctx, sealed); err != nil {
+		return err
+	}
+	copyMeta(cred, sealed)
+	return nil
+}
+
+func (s *credentialStore) Delete(ctx context.Context, id string) error {
+	return s.inner.Delete(ctx, id)
+}
+
+// seal 返回只含密文的凭据副本
+func (s *credentialStore) seal(cred *models.Credential) (*models.Credential, error) {
+	if s.cipher == nil {
+		return nil, ErrNoEncryptionKey
+	}
+
+	data, err := s.cipher.Seal(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := stripSecrets(cred)
+	sealed.EncryptedData = data
+	return sealed, nil
+}
+
+// open 解密凭据，旧版本的明文凭据直接解析
+func (s *credentialStore) open(cred *models.Credential) error {
+	switch {
+	case IsEncrypted(cred):
+		if s.cipher == nil {
+			return ErrNoEncryptionKey
+		}
+		return s.cipher.Open(cred)
+	case IsLegacyPlaintext(cred):
+		return OpenLegacy(cred)
+	}
+	return nil
+}
+
+// stripSecrets 复制凭据并清空明文敏感字段
+func stripSecrets(cred *models.Credential) *models.Credential {
+	c := *cred
+	c.Username = ""
+	c.Password = ""
+	c.PrivateKey = ""
+	c.Passphrase = ""
+	c.KnownHosts = ""
+	c.Host = ""
+	c.TrustNewHosts = false
+	return &c
+}
+
+// copyMeta 将存储层生成的元信息写回调用方的凭据
+func copyMeta(dst, src *models.Credential) {
+	dst.ID = src.ID
+	dst.EncryptedData = src.EncryptedData
+	dst.CreatedAt = src.CreatedAt
+	dst.UpdatedAt = src.UpdatedAt
+}
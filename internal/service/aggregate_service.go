@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/cache"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/stats"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/teams"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
+)
+
+// aggregateTimeout 单次聚合等待各仓库统计任务的最长时间
+const aggregateTimeout = 2 * time.Hour
+
+// failedJobRetention 失败的聚合保留在内存中供查询的时间
+const failedJobRetention = time.Hour
+
+// ErrAggregateNotFound 聚合结果不存在
+var ErrAggregateNotFound = errors.New("aggregate not found, please submit aggregate request first")
+
+// AggregateService 跨仓库聚合统计服务
+type AggregateService struct {
+	store        storage.Store
+	queue        *worker.Queue
+	cache        *cache.FileCache
+	gitManager   git.Manager
+	teams        *teams.Registry
+	statsService *StatsService
+
+	mu   sync.Mutex
+	jobs map[string]*aggregateJob
+}
+
+// aggregateJob 进行中的聚合任务
+type aggregateJob struct {
+	status  string
+	err     string
+	taskIDs []int64
+}
+
+// NewAggregateService 创建跨仓库聚合统计服务
+func NewAggregateService(store storage.Store, queue *worker.Queue, fileCache *cache.FileCache, gitManager git.Manager,
+	teamRegistry *teams.Registry, statsService *StatsService) *AggregateService {
+	return &AggregateService{
+		store:        store,
+		queue:        queue,
+		cache:        fileCache,
+		gitManager:   gitManager,
+		teams:        teamRegistry,
+		statsService: statsService,
+		jobs:         make(map[string]*aggregateJob),
+	}
+}
+
+// AggregateRequest 跨仓库聚合统计请求
+type AggregateRequest struct {
+	RepoIDs    []int64                 `json:"repo_ids,omitempty"` // 与label二选一
+	Label      string                  `json:"label,omitempty"`    // 与repo_ids二选一
+	Branch     string                  `json:"branch,omitempty"`   // 为空时使用各仓库当前分支
+	Constraint *models.StatsConstraint `json:"constraint"`
+}
+
+// AggregateResponse 跨仓库聚合统计响应
+type AggregateResponse struct {
+	AggregateKey string                  `json:"aggregate_key"`
+	Status       string                  `json:"status"` // running/completed/failed
+	Error        string                  `json:"error,omitempty"`
+	TaskIDs      []int64                 `json:"task_ids,omitempty"`
+	Result       *models.AggregateResult `json:"result,omitempty"`
+}
+
+// Submit 提交跨仓库聚合统计，命中缓存时直接返回结果
+func (s *AggregateService) Submit(ctx context.Context, req *AggregateRequest) (*AggregateResponse, error) {
+	if err := ValidateStatsConstraint(req.Constraint); err != nil {
+		return nil, err
+	}
+
+	repos, err := s.resolveRepos(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, err := s.teams.Mapper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team mapping: %w", err)
+	}
+
+	// 聚合键由各仓库的统计缓存键决定
+	members := make([]models.AggregateRepo, 0, len(repos))
+	for _, repo := range repos {
+		branch := req.Branch
+		if branch == "" {
+			branch = repo.CurrentBranch
+		}
+
+		commitHash, err := s.gitManager.ResolveRef(ctx, repo.LocalPath, branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve branch %s of repository %d: %w", branch, repo.ID, err)
+		}
+
+		members = append(members, models.AggregateRepo{
+			RepoID:     repo.ID,
+			Name:       repo.Name,
+			Branch:     branch,
+			CommitHash: commitHash,
+			CacheKey:   cache.GenerateCacheKey(repo.ID, branch, req.Constraint, commitHash, mapper.Version()),
+		})
+	}
+	aggregateKey := generateAggregateKey(members)
+
+	// 查询聚合缓存
+	cached, err := s.cache.GetAggregate(ctx, aggregateKey)
+	if err != nil {
+		logger.Logger.Warn().Err(err).Str("aggregate_key", aggregateKey).Msg("failed to get aggregate cache")
+	}
+	if cached != nil {
+		return &AggregateResponse{
+			AggregateKey: aggregateKey,
+			Status:       models.TaskStatusCompleted,
+			Result:       cached,
+		}, nil
+	}
+
+	if resp := s.runningJob(aggregateKey); resp != nil {
+		return resp, nil
+	}
+
+	// 为每个仓库提交统计任务（队列会对相同任务去重），提交过程需要调用git，不持有锁
+	job := &aggregateJob{
+		status:  models.TaskStatusRunning,
+		taskIDs: make([]int64, 0, len(members)),
+	}
+	for _, member := range members {
+		task, err := s.statsService.Calculate(ctx, &CalculateRequest{
+			RepoID:     member.RepoID,
+			Branch:     member.Branch,
+			Constraint: req.Constraint,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to submit stats task for repository %d: %w", member.RepoID, err)
+		}
+		job.taskIDs = append(job.taskIDs, task.ID)
+	}
+
+	// 并发提交的相同聚合已经开始时复用它，本次提交的任务已被队列去重
+	s.mu.Lock()
+	if running, ok := s.jobs[aggregateKey]; ok && running.status == models.TaskStatusRunning {
+		resp := running.response(aggregateKey)
+		s.mu.Unlock()
+		return resp, nil
+	}
+	s.jobs[aggregateKey] = job
+	resp := job.response(aggregateKey)
+	s.mu.Unlock()
+
+	go s.run(aggregateKey, job, members, req.Constraint, mapper)
+
+	logger.Logger.Info().
+		Str("aggregate_key", aggregateKey).
+		Int("repos", len(members)).
+		Msg("aggregate stats submitted")
+
+	return resp, nil
+}
+
+// Get 查询跨仓库聚合结果
+func (s *AggregateService) Get(ctx context.Context, aggregateKey string) (*AggregateResponse, error) {
+	cached, err := s.cache.GetAggregate(ctx, aggregateKey)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		return &AggregateResponse{
+			AggregateKey: aggregateKey,
+			Status:       models.TaskStatusCompleted,
+			Result:       cached,
+		}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[aggregateKey]; ok {
+		return job.response(aggregateKey), nil
+	}
+
+	return nil, ErrAggregateNotFound
+}
+
+// runningJob 返回进行中的聚合的状态，没有进行中的聚合时返回nil
+func (s *AggregateService) runningJob(aggregateKey string) *AggregateResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[aggregateKey]; ok && job.status == models.TaskStatusRunning {
+		return job.response(aggregateKey)
+	}
+	return nil
+}
+
+// resolveRepos 根据仓库ID或标签确定参与聚合的仓库
+func (s *AggregateService) resolveRepos(ctx context.Context, req *AggregateRequest) ([]*models.Repository, error) {
+	if (len(req.RepoIDs) == 0) == (req.Label == "") {
+		return nil, errors.New("exactly one of repo_ids or label is required")
+	}
+
+	repos := make([]*models.Repository, 0)
+	if req.Label != "" {
+		all, err := listAllRepos(ctx, s.store, models.RepoStatusReady)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories: %w", err)
+		}
+		for _, repo := range all {
+			if hasLabel(repo, req.Label) {
+				repos = append(repos, repo)
+			}
+		}
+		if len(repos) == 0 {
+			return nil, fmt.Errorf("no ready repository with label %s", req.Label)
+		}
+	} else {
+		seen := make(map[int64]bool, len(req.RepoIDs))
+		for _, id := range req.RepoIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			repo, err := s.store.Repos().GetByID(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get repository %d: %w", id, err)
+			}
+			if repo == nil {
+				return nil, fmt.Errorf("repository %d not found", id)
+			}
+			if repo.Status != models.RepoStatusReady {
+				return nil, fmt.Errorf("repository %d is not ready", id)
+			}
+			repos = append(repos, repo)
+		}
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].ID < repos[j].ID
+	})
+
+	return repos, nil
+}
+
+// run 等待各仓库统计任务完成后合并结果
+func (s *AggregateService) run(aggregateKey string, job *aggregateJob, members []models.AggregateRepo,
+	constraint *models.StatsConstraint, mapper *teams.Mapper) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), aggregateTimeout)
+	defer cancel()
+
+	err := s.merge(ctx, aggregateKey, job, members, constraint, mapper)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		logger.Logger.Error().Err(err).Str("aggregate_key", aggregateKey).Msg("aggregate stats failed")
+		job.status = models.TaskStatusFailed
+		job.err = err.Error()
+
+		// 失败原因保留一段时间供查询，之后删除，重新提交会创建新的聚合
+		time.AfterFunc(failedJobRetention, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.jobs[aggregateKey] == job {
+				delete(s.jobs, aggregateKey)
+			}
+		})
+		return
+	}
+
+	// 结果已落盘，之后由缓存提供
+	delete(s.jobs, aggregateKey)
+}
+
+// merge 加载各仓库统计结果、合并并保存聚合缓存
+func (s *AggregateService) merge(ctx context.Context, aggregateKey string, job *aggregateJob, members []models.AggregateRepo,
+	constraint *models.StatsConstraint, mapper *teams.Mapper) error {
+
+	parts := make([]*models.Statistics, 0, len(members))
+	for i, taskID := range job.taskIDs {
+		task, err := s.queue.Wait(ctx, taskID)
+		if err != nil {
+			return fmt.Errorf("failed to wait for stats task %d: %w", taskID, err)
+		}
+		if task.Status != models.TaskStatusCompleted {
+			return fmt.Errorf("stats task %d for repository %d ended with status %s", taskID, task.RepoID, task.Status)
+		}
+		if task.Result == nil {
+			return fmt.Errorf("stats task %d has no result", taskID)
+		}
+
+		var taskResult models.TaskResult
+		if err := json.Unmarshal([]byte(*task.Result), &taskResult); err != nil {
+			return fmt.Errorf("failed to parse result of stats task %d: %w", taskID, err)
+		}
+
+		result, err := s.cache.Get(ctx, taskResult.CacheKey)
+		if err != nil {
+			return fmt.Errorf("failed to load statistics of repository %d: %w", task.RepoID, err)
+		}
+		if result == nil {
+			return fmt.Errorf("statistics of repository %d not found in cache", task.RepoID)
+		}
+
+		members[i].CacheKey = taskResult.CacheKey
+		members[i].CommitHash = result.CommitHash
+		parts = append(parts, result.Statistics)
+	}
+
+	merged := stats.Merge(parts)
+	merged.ByTeam = mapper.Aggregate(merged.ByContributor)
+	if constraint.Type == models.ConstraintTypeDateRange {
+		merged.Summary.DateRange = &models.DateRange{
+			From: constraint.From,
+			To:   constraint.To,
+		}
+	} else if constraint.Type == models.ConstraintTypeCommitLimit {
+		limit := constraint.Limit
+		merged.Summary.CommitLimit = &limit
+	}
+
+	result := &models.AggregateResult{
+		Repos:      members,
+		Statistics: merged,
+	}
+	if err := s.cache.SetAggregate(ctx, aggregateKey, result); err != nil {
+		return err
+	}
+
+	logger.Logger.Info().
+		Str("aggregate_key", aggregateKey).
+		Int("repos", len(members)).
+		Int("total_commits", merged.Summary.TotalCommits).
+		Int("contributors", merged.Summary.TotalContributors).
+		Msg("aggregate stats calculated")
+
+	return nil
+}
+
+// response 转换为响应结构
+func (j *aggregateJob) response(aggregateKey string) *AggregateResponse {
+	return &AggregateResponse{
+		AggregateKey: aggregateKey,
+		Status:       j.status,
+		Error:        j.err,
+		TaskIDs:      j.taskIDs,
+	}
+}
+
+// generateAggregateKey 由各仓库统计缓存键生成聚合键
+func generateAggregateKey(members []models.AggregateRepo) string {
+	keys := make([]string, 0, len(members))
+	for _, member := range members {
+		keys = append(keys, member.CacheKey)
+	}
+
+	hash := sha256.Sum256([]byte("aggregate|" + strings.Join(keys, "|")))
+	return hex.EncodeToString(hash[:])
+}
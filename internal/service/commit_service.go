@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/stats"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+const (
+	defaultCommitPageSize = 20
+	maxCommitPageSize     = 100
+
+	defaultDiffFiles    = 50
+	maxDiffFiles        = 500
+	defaultDiffMaxBytes = 512 * 1024
+	maxDiffMaxBytes     = 4 * 1024 * 1024
+)
+
+var (
+	// ErrInvalidCommitQuery 提交查询参数无效
+	ErrInvalidCommitQuery = errors.New("invalid commit query")
+	// ErrRepoNotFound 仓库不存在
+	ErrRepoNotFound = errors.New("repository not found")
+	// ErrRepoNotReady 仓库尚未克隆完成
+	ErrRepoNotReady = errors.New("repository is not ready")
+	// ErrCommitNotFound 提交不存在
+	ErrCommitNotFound = errors.New("commit not found")
+)
+
+var (
+	// shaPattern 完整的commit hash
+	shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	// shaArgPattern 接口接受的commit hash，允许至少4位的缩写
+	shaArgPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+)
+
+// CommitService 提交浏览服务
+type CommitService struct {
+	store      storage.Store
+	gitManager git.Manager
+	calculator *stats.Calculator
+}
+
+// NewCommitService 创建提交浏览服务
+func NewCommitService(store storage.Store, gitManager git.Manager, calculator *stats.Calculator) *CommitService {
+	return &CommitService{
+		store:      store,
+		gitManager: gitManager,
+		calculator: calculator,
+	}
+}
+
+// ListCommitsRequest 提交列表请求
+type ListCommitsRequest struct {
+	RepoID        int64
+	Ref           string // 分支、标签或commit，为空时使用仓库当前分支
+	Author        string
+	Path          string
+	Since         string // YYYY-MM-DD 或 RFC3339
+	Until         string // YYYY-MM-DD 或 RFC3339，纯日期包含当天
+	Message       string
+	IncludeMerges bool
+	Limit         int
+	Cursor        string // 上一页返回的 next_cursor
+}
+
+// ListCommitsResponse 提交列表响应
+type ListCommitsResponse struct {
+	Ref        string          `json:"ref"`
+	SHA        string          `json:"sha"` // 分页期间固定的起始提交
+	Commits    []models.Commit `json:"commits"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
+}
+
+// ListCommits 分页列出提交
+//
+// 第一页将引用解析为commit hash并编码进游标，后续翻页不受分支上新提交的影响。
+func (s *CommitService) ListCommits(ctx context.Context, req *ListCommitsRequest) (*ListCommitsResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultCommitPageSize
+	}
+	if limit > maxCommitPageSize {
+		return nil, fmt.Errorf("%w: limit must be at most %d", ErrInvalidCommitQuery, maxCommitPageSize)
+	}
+
+	query := &stats.CommitQuery{
+		Author:        strings.TrimSpace(req.Author),
+		Path:          strings.Trim(strings.TrimSpace(req.Path), "/"),
+		Message:       strings.TrimSpace(req.Message),
+		IncludeMerges: req.IncludeMerges,
+		Limit:         limit + 1, // 多取一条用于判断是否还有下一页
+	}
+	if req.Since != "" {
+		since, err := git.ParseDate(req.Since, false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCommitQuery, err)
+		}
+		query.Since = &since
+	}
+	if req.Until != "" {
+		until, err := git.ParseDate(req.Until, true)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCommitQuery, err)
+		}
+		query.Until = &until
+	}
+	if query.Since != nil && query.Until != nil && query.Since.After(*query.Until) {
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidCommitQuery)
+	}
+
+	repo, err := s.store.Repos().GetByID(ctx, req.RepoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+	if repo == nil {
+		return nil, ErrRepoNotFound
+	}
+	if repo.Status != models.RepoStatusReady {
+		return nil, fmt.Errorf("%w, status: %s", ErrRepoNotReady, repo.Status)
+	}
+
+	ref := req.Ref
+	if ref == "" {
+		ref = repo.CurrentBranch
+	}
+
+	var sha string
+	if req.Cursor != "" {
+		if sha, query.Skip, err = decodeCommitCursor(req.Cursor); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := git.ValidateRef(ref); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCommitQuery, err)
+		}
+		if sha, err = s.gitManager.ResolveRef(ctx, repo.LocalPath, ref); err != nil {
+			return nil, fmt.Errorf("failed to resolve ref %s: %w", ref, err)
+		}
+	}
+	query.Rev = sha
+
+	commits, err := s.calculator.ListCommits(s.withCredential(ctx, repo), repo.LocalPath, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	resp := &ListCommitsResponse{
+		Ref:     ref,
+		SHA:     sha,
+		Commits: commits,
+	}
+	if len(commits) > limit {
+		resp.Commits = commits[:limit]
+		resp.HasMore = true
+		resp.NextCursor = encodeCommitCursor(sha, query.Skip+limit)
+	}
+
+	return resp, nil
+}
+
+// GetCommitRequest 提交详情请求
+type GetCommitRequest struct {
+	RepoID       int64
+	SHA          string
+	WithDiff     bool
+	DiffOffset   int // 从第几个文件开始
+	DiffLimit    int // 每页文件数
+	DiffMaxBytes int // 每页patch字节数上限
+}
+
+// GetCommit 获取提交详情，可选附带unified diff
+//
+// sha 只接受十六进制commit hash，解析后必须以其为前缀，避免与同名分支或标签混淆，也不会有任意参数传给git。
+func (s *CommitService) GetCommit(ctx context.Context, req *GetCommitRequest) (*models.CommitDetail, error) {
+	if !shaArgPattern.MatchString(req.SHA) {
+		return nil, fmt.Errorf("%w: invalid sha %s", ErrInvalidCommitQuery, req.SHA)
+	}
+
+	opts := &stats.DiffOptions{
+		Offset:   req.DiffOffset,
+		Limit:    req.DiffLimit,
+		MaxBytes: req.DiffMaxBytes,
+	}
+	if opts.Offset < 0 {
+		return nil, fmt.Errorf("%w: diff_offset must be non-negative", ErrInvalidCommitQuery)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultDiffFiles
+	}
+	if opts.Limit > maxDiffFiles {
+		return nil, fmt.Errorf("%w: diff_limit must be at most %d", ErrInvalidCommitQuery, maxDiffFiles)
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultDiffMaxBytes
+	}
+	if opts.MaxBytes > maxDiffMaxBytes {
+		return nil, fmt.Errorf("%w: max_diff_bytes must be at most %d", ErrInvalidCommitQuery, maxDiffMaxBytes)
+	}
+
+	repo, err := s.store.Repos().GetByID(ctx, req.RepoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+	if repo == nil {
+		return nil, ErrRepoNotFound
+	}
+	if repo.Status != models.RepoStatusReady {
+		return nil, fmt.Errorf("%w, status: %s", ErrRepoNotReady, repo.Status)
+	}
+
+	sha, err := s.gitManager.ResolveRef(ctx, repo.LocalPath, req.SHA)
+	if err != nil || !strings.HasPrefix(sha, strings.ToLower(req.SHA)) {
+		return nil, fmt.Errorf("%w: %s", ErrCommitNotFound, req.SHA)
+	}
+
+	ctx = s.withCredential(ctx, repo)
+	detail, err := s.calculator.GetCommit(ctx, repo.LocalPath, sha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	if req.WithDiff {
+		if detail.Diff, err = s.calculator.ReadDiff(ctx, repo.LocalPath, sha, detail.Parents, opts); err != nil {
+			return nil, fmt.Errorf("failed to read diff: %w", err)
+		}
+	}
+
+	return detail, nil
+}
+
+// withCredential 为读取命令附加仓库凭据，部分克隆读取numstat和diff时会按需从远程拉取对象
+func (s *CommitService) withCredential(ctx context.Context, repo *models.Repository) context.Context {
+	if repo.CredentialID == nil {
+		return ctx
+	}
+	cred, _ := s.store.Credentials().GetByID(ctx, *repo.CredentialID)
+	if cred == nil || git.CheckCredentialHost(cred, repo.URL) != nil {
+		return ctx
+	}
+	return git.WithCredential(ctx, cred)
+}
+
+// encodeCommitCursor 游标由起始提交和已跳过的提交数组成
+func encodeCommitCursor(sha string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sha + ":" + strconv.Itoa(offset)))
+}
+
+// decodeCommitCursor 解析游标
+func decodeCommitCursor(cursor string) (string, int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: invalid cursor", ErrInvalidCommitQuery)
+	}
+
+	sha, offsetStr, ok := strings.Cut(string(data), ":")
+	if !ok || !shaPattern.MatchString(sha) {
+		return "", 0, fmt.Errorf("%w: invalid cursor", ErrInvalidCommitQuery)
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return "", 0, fmt.Errorf("%w: invalid cursor", ErrInvalidCommitQuery)
+	}
+
+	return sha, offset, nil
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+// ErrCredentialNotFound 凭据不存在
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// credentialTestTimeout 测试凭据时 git ls-remote 的超时时间
+const credentialTestTimeout = 30 * time.Second
+
+// CredentialService 凭据服务
+type CredentialService struct {
+	store      storage.Store
+	gitManager git.Manager
+}
+
+// NewCredentialService 创建凭据服务
+func NewCredentialService(store storage.Store, gitManager git.Manager) *CredentialService {
+	return &CredentialService{
+		store:      store,
+		gitManager: gitManager,
+	}
+}
+
+// CredentialRequest 创建/更新凭据请求
+//
+// 更新时敏感字段留空表示保持原值，用于只修改名称；轮换令牌或私钥时直接提交新值。
+type CredentialRequest struct {
+	Name       string `json:"name"`
+	AuthType   string `json:"auth_type"` // basic/token/ssh
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`    // auth_type=basic
+	Token      string `json:"token,omitempty"`       // auth_type=token
+	PrivateKey string `json:"private_key,omitempty"` // auth_type=ssh
+	Passphrase string `json:"passphrase,omitempty"`  // auth_type=ssh
+	KnownHosts string `json:"known_hosts,omitempty"` // auth_type=ssh，可选
+
+	// TrustNewHosts auth_type=ssh 且未提供known_hosts时，首次连接接受并记录未知主机密钥；
+	// 默认只信任系统known_hosts中的主机。更新时为空表示保持原值
+	TrustNewHosts *bool `json:"trust_new_hosts,omitempty"`
+
+	// Host 凭据绑定的主机（如 github.com，也可填写仓库地址），只能用于该主机上的仓库；
+	// 修改主机时必须同时提交新的密码/令牌/私钥，避免把已保存的密钥转用到其他主机
+	Host string `json:"host,omitempty"`
+}
+
+// CredentialInfo 凭据信息（不含密码、令牌、私钥）
+type CredentialInfo struct {
+	*models.Credential
+	HasSecret bool    `json:"has_secret"` // 是否已设置密码/令牌/私钥
+	RepoIDs   []int64 `json:"repo_ids"`   // 使用该凭据的仓库
+}
+
+// TestCredentialRequest 测试凭据请求，url 与 repo_id 二选一
+type TestCredentialRequest struct {
+	URL    string `json:"url,omitempty"`
+	RepoID int64  `json:"repo_id,omitempty"`
+}
+
+// TestCredentialResponse 测试凭据结果
+type TestCredentialResponse struct {
+	URL     string `json:"url"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ListCredentials 获取凭据列表
+func (s *CredentialService) ListCredentials(ctx context.Context) ([]*CredentialInfo, error) {
+	creds, err := s.store.Credentials().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	usage, err := s.credentialUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*CredentialInfo, 0, len(creds))
+	for _, cred := range creds {
+		infos = append(infos, newCredentialInfo(cred, usage[cred.ID]))
+	}
+
+	return infos, nil
+}
+
+// GetCredential 获取凭据信息
+func (s *CredentialService) GetCredential(ctx context.Context, id string) (*CredentialInfo, error) {
+	cred, err := s.getCredential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.credentialUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredentialInfo(cred, usage[cred.ID]), nil
+}
+
+// CreateCredential 创建凭据
+func (s *CredentialService) CreateCredential(ctx context.Context, req *CredentialRequest) (*CredentialInfo, error) {
+	cred := &models.Credential{
+		ID:       generateCredentialID(),
+		AuthType: req.AuthType,
+		Host:     git.NormalizeHost(req.Host),
+	}
+	applyCredentialRequest(cred, req)
+
+	if err := validateCredential(cred); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Credentials().Create(ctx, cred); err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	logger.Logger.Info().
+		Str("credential_id", cred.ID).
+		Str("auth_type", cred.AuthType).
+		Msg("credential created")
+
+	return newCredentialInfo(cred, nil), nil
+}
+
+// UpdateCredential 更新凭据（名称、用户名或轮换密钥）
+//
+// 使用该凭据的所有仓库在下次拉取时自动使用新值，无需重新添加仓库。
+func (s *CredentialService) UpdateCredential(ctx context.Context, id string, req *CredentialRequest) (*CredentialInfo, error) {
+	cred, err := s.getCredential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 切换认证类型或主机时旧的密钥不再适用
+	host := git.NormalizeHost(req.Host)
+	hostChanged := host != "" && host != cred.Host
+	if (req.AuthType != "" && req.AuthType != cred.AuthType) || hostChanged {
+		if req.AuthType != "" {
+			cred.AuthType = req.AuthType
+		}
+		cred.Password = ""
+		cred.PrivateKey = ""
+		cred.Passphrase = ""
+		cred.KnownHosts = ""
+		cred.TrustNewHosts = false
+	}
+	if hostChanged {
+		cred.Host = host
+	}
+	applyCredentialRequest(cred, req)
+
+	if err := validateCredential(cred); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Credentials().Update(ctx, cred); err != nil {
+		return nil, fmt.Errorf("failed to update credential: %w", err)
+	}
+
+	usage, err := s.credentialUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Logger.Info().
+		Str("credential_id", cred.ID).
+		Str("auth_type", cred.AuthType).
+		Int("repos", len(usage[cred.ID])).
+		Msg("credential updated")
+
+	return newCredentialInfo(cred, usage[cred.ID]), nil
+}
+
+// DeleteCredential 删除凭据，仍被仓库使用时拒绝删除
+func (s *CredentialService) DeleteCredential(ctx context.Context, id string) error {
+	if _, err := s.getCredential(ctx, id); err != nil {
+		return err
+	}
+
+	usage, err := s.credentialUsage(ctx)
+	if err != nil {
+		return err
+	}
+	if repoIDs := usage[id]; len(repoIDs) > 0 {
+		return fmt.Errorf("credential is used by %d repositories, detach it first", len(repoIDs))
+	}
+
+	if err := s.store.Credentials().Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete credential: %w", err)
+	}
+
+	logger.Logger.Info().Str("credential_id", id).Msg("credential deleted")
+
+	return nil
+}
+
+// TestCredential 使用 git ls-remote 验证凭据能否访问仓库，仓库必须位于凭据绑定的主机上
+func (s *CredentialService) TestCredential(ctx context.Context, id string, req *TestCredentialRequest) (*TestCredentialResponse, error) {
+	cred, err := s.getCredential(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	url := req.URL
+	if req.RepoID != 0 {
+		repo, err := s.store.Repos().GetByID(ctx, req.RepoID)
+		if err != nil {
+			return nil, err
+		}
+		if repo == nil {
+			return nil, errors.New("repository not found")
+		}
+		url = repo.URL
+	}
+	if !isValidGitURL(url) {
+		return nil, errors.New("invalid git URL")
+	}
+	// 本地仓库不使用凭据，也不能借此探测 local_repo_roots 以外的路径
+	if git.IsLocalURL(url) {
+		return nil, errors.New("credentials cannot be tested against local repositories")
+	}
+	if err := git.CheckCredentialHost(cred, url); err != nil {
+		return nil, err
+	}
+
+	testCtx, cancel := context.WithTimeout(ctx, credentialTestTimeout)
+	defer cancel()
+
+	resp := &TestCredentialResponse{URL: url, Success: true}
+	if err := s.gitManager.LsRemote(testCtx, url, cred); err != nil {
+		resp.Success = false
+		resp.Error = err.Error()
+	}
+
+	logger.Logger.Info().
+		Str("credential_id", id).
+		Str("url", url).
+		Bool("success", resp.Success).
+		Msg("credential tested")
+
+	return resp, nil
+}
+
+// getCredential 获取凭据，不存在时返回 ErrCredentialNotFound
+func (s *CredentialService) getCredential(ctx context.Context, id string) (*models.Credential, error) {
+	cred, err := s.store.Credentials().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, ErrCredentialNotFound
+	}
+	return cred, nil
+}
+
+// credentialUsage 统计每个凭据被哪些仓库使用
+func (s *CredentialService) credentialUsage(ctx context.Context) (map[string][]int64, error) {
+	repos, err := listAllRepos(ctx, s.store, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	usage := make(map[string][]int64)
+	for _, repo := range repos {
+		if repo.CredentialID != nil {
+			usage[*repo.CredentialID] = append(usage[*repo.CredentialID], repo.ID)
+		}
+	}
+
+	return usage, nil
+}
+
+// applyCredentialRequest 将请求中的非空字段写入凭据
+func applyCredentialRequest(cred *models.Credential, req *CredentialRequest) {
+	if name := strings.TrimSpace(req.Name); name != "" {
+		cred.Name = name
+	}
+	if req.Username != "" {
+		cred.Username = req.Username
+	}
+
+	switch cred.AuthType {
+	case models.AuthTypeBasic:
+		if req.Password != "" {
+			cred.Password = req.Password
+		}
+	case models.AuthTypeToken:
+		if req.Token != "" {
+			cred.Password = req.Token
+		}
+	case models.AuthTypeSSH:
+		if req.PrivateKey != "" {
+			cred.PrivateKey = req.PrivateKey
+			cred.Passphrase = req.Passphrase
+		}
+		if req.KnownHosts != "" {
+			cred.KnownHosts = req.KnownHosts
+		}
+		if req.TrustNewHosts != nil {
+			cred.TrustNewHosts = *req.TrustNewHosts
+		}
+	}
+}
+
+// validateCredential 校验凭据完整性
+func validateCredential(cred *models.Credential) error {
+	switch cred.AuthType {
+	case models.AuthTypeBasic:
+		if cred.Username == "" || cred.Password == "" {
+			return fmt.Errorf("%s credential requires username and password", models.AuthTypeBasic)
+		}
+	case models.AuthTypeToken:
+		if cred.Password == "" {
+			return fmt.Errorf("%s credential requires token", models.AuthTypeToken)
+		}
+	case models.AuthTypeSSH:
+		if err := ValidateSSHKey(cred.PrivateKey, cred.Passphrase); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("auth_type must be one of %s, %s, %s", models.AuthTypeBasic, models.AuthTypeToken, models.AuthTypeSSH)
+	}
+
+	if cred.Host == "" {
+		return errors.New("host is required")
+	}
+
+	return nil
+}
+
+// newCredentialInfo 构造不含敏感字段的凭据信息
+func newCredentialInfo(cred *models.Credential, repoIDs []int64) *CredentialInfo {
+	if repoIDs == nil {
+		repoIDs = make([]int64, 0)
+	}
+
+	return &CredentialInfo{
+		Credential: cred,
+		HasSecret:  cred.Password != "" || cred.PrivateKey != "",
+		RepoIDs:    repoIDs,
+	}
+}
+
+// BindCredentialHosts 为旧版本创建的、未绑定主机的凭据绑定主机，返回绑定的数量
+//
+// 只有所绑定仓库都位于同一主机时才自动绑定；其余凭据在重新提交主机和密钥之前不能使用。
+func BindCredentialHosts(ctx context.Context, store storage.Store) (int, error) {
+	creds, err := store.Credentials().List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	repos, err := listAllRepos(ctx, store, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	hosts := make(map[string]map[string]bool)
+	for _, repo := range repos {
+		host := git.RepoHost(repo.URL)
+		if repo.CredentialID == nil || host == "" {
+			continue
+		}
+		if hosts[*repo.CredentialID] == nil {
+			hosts[*repo.CredentialID] = make(map[string]bool)
+		}
+		hosts[*repo.CredentialID][host] = true
+	}
+
+	bound := 0
+	for _, cred := range creds {
+		if cred.Host != "" {
+			continue
+		}
+
+		if len(hosts[cred.ID]) != 1 {
+			logger.Logger.Warn().
+				Str("credential_id", cred.ID).
+				Int("hosts", len(hosts[cred.ID])).
+				Msg("credential is not bound to a host and cannot be used until host and secret are updated")
+			continue
+		}
+		for host := range hosts[cred.ID] {
+			cred.Host = host
+		}
+
+		if err := store.Credentials().Update(ctx, cred); err != nil {
+			return bound, fmt.Errorf("failed to bind credential %s: %w", cred.ID, err)
+		}
+		logger.Logger.Info().Str("credential_id", cred.ID).Str("host", cred.Host).Msg("credential bound to host")
+		bound++
+	}
+
+	return bound, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/webhook"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
+)
+
+// hookFollowUpTimeout 等待拉取完成并提交统计任务的最长时间
+const hookFollowUpTimeout = 30 * time.Minute
+
+// HookService 处理代码托管平台推送的webhook
+type HookService struct {
+	store        storage.Store
+	queue        *worker.Queue
+	statsService *StatsService
+	secrets      map[string]string
+	maxPayload   int64
+	recalculate  []config.RecalculateConfig
+
+	// 拉取后重新计算使用的context，Stop时取消并等待结束
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewHookService 创建webhook服务
+func NewHookService(store storage.Store, queue *worker.Queue, statsService *StatsService, cfg config.InboundWebhookConfig) *HookService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &HookService{
+		store:        store,
+		queue:        queue,
+		statsService: statsService,
+		secrets: map[string]string{
+			webhook.ProviderGitHub: cfg.GitHubSecret,
+			webhook.ProviderGitLab: cfg.GitLabToken,
+			webhook.ProviderGitea:  cfg.GiteaSecret,
+		},
+		maxPayload:  cfg.MaxPayloadSize,
+		recalculate: cfg.Recalculate,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Stop 取消等待中的重新计算并等待其退出，需在worker池停止之前调用
+func (s *HookService) Stop() {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// MaxPayloadSize 请求体大小上限
+func (s *HookService) MaxPayloadSize() int64 {
+	return s.maxPayload
+}
+
+// HookResult webhook处理结果
+type HookResult struct {
+	Event *webhook.PushEvent `json:"event"`
+	Repos []HookRepoResult   `json:"repos"`
+}
+
+// HookRepoResult 单个匹配仓库的处理结果
+type HookRepoResult struct {
+	RepoID      int64  `json:"repo_id"`
+	PullTaskID  int64  `json:"pull_task_id,omitempty"`
+	Recalculate bool   `json:"recalculate"`       // 拉取完成后是否重新计算统计
+	Skipped     string `json:"skipped,omitempty"` // 未处理的原因
+}
+
+// Receive 校验并解析webhook请求，非push事件返回 webhook.ErrIgnoredEvent
+func (s *HookService) Receive(provider string, header http.Header, body []byte) (*webhook.PushEvent, error) {
+	p, err := webhook.NewProvider(provider, s.secrets[provider])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Verify(header, body); err != nil {
+		return nil, err
+	}
+
+	return p.ParsePush(header, body)
+}
+
+// HandlePush 按仓库地址匹配push事件对应的仓库，提交pull任务
+//
+// 推送的是仓库当前分支时，在拉取完成后按配置重新计算统计。
+func (s *HookService) HandlePush(ctx context.Context, event *webhook.PushEvent) (*HookResult, error) {
+	repos, err := s.matchRepos(ctx, event.RepoURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HookResult{
+		Event: event,
+		Repos: make([]HookRepoResult, 0, len(repos)),
+	}
+
+	for _, repo := range repos {
+		item := HookRepoResult{RepoID: repo.ID}
+		if repo.Status != models.RepoStatusReady {
+			item.Skipped = fmt.Sprintf("repository is not ready, status: %s", repo.Status)
+			result.Repos = append(result.Repos, item)
+			continue
+		}
+
+		task := &models.Task{
+			TaskType: models.TaskTypePull,
+			RepoID:   repo.ID,
+			Priority: 0,
+		}
+		if err := s.queue.Enqueue(ctx, task); err != nil {
+			return nil, fmt.Errorf("failed to enqueue pull task for repository %d: %w", repo.ID, err)
+		}
+		item.PullTaskID = task.ID
+
+		if len(s.recalculate) > 0 && !event.Deleted && event.Branch != "" && event.Branch == repo.CurrentBranch {
+			item.Recalculate = s.goRecalculate(repo.ID, repo.CurrentBranch, task.ID)
+		}
+
+		logger.Logger.Info().
+			Str("provider", event.Provider).
+			Str("ref", event.Ref).
+			Int64("repo_id", repo.ID).
+			Int64("task_id", task.ID).
+			Bool("recalculate", item.Recalculate).
+			Msg("webhook pull task submitted")
+
+		result.Repos = append(result.Repos, item)
+	}
+
+	return result, nil
+}
+
+// matchRepos 返回地址与事件中任一地址规范化后相同的仓库
+func (s *HookService) matchRepos(ctx context.Context, urls []string) ([]*models.Repository, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("push event has no repository url")
+	}
+
+	wanted := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		wanted[git.NormalizeRepoURL(u)] = true
+	}
+
+	all, err := listAllRepos(ctx, s.store, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	repos := make([]*models.Repository, 0)
+	for _, repo := range all {
+		if wanted[git.NormalizeRepoURL(repo.URL)] {
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos, nil
+}
+
+// goRecalculate 在后台等待拉取完成后重新计算，服务已停止时返回false
+func (s *HookService) goRecalculate(repoID int64, branch string, pullTaskID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return false
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.recalculateAfterPull(repoID, branch, pullTaskID)
+	}()
+	return true
+}
+
+// recalculateAfterPull 等待拉取完成后按配置提交统计任务
+func (s *HookService) recalculateAfterPull(repoID int64, branch string, pullTaskID int64) {
+	ctx, cancel := context.WithTimeout(s.ctx, hookFollowUpTimeout)
+	defer cancel()
+
+	task, err := s.queue.Wait(ctx, pullTaskID)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("task_id", pullTaskID).Msg("failed to wait for webhook pull task")
+		return
+	}
+	if task.Status != models.TaskStatusCompleted {
+		logger.Logger.Warn().
+			Int64("repo_id", repoID).
+			Int64("task_id", pullTaskID).
+			Str("status", task.Status).
+			Msg("webhook pull task did not complete, skipping stats recalculation")
+		return
+	}
+
+	now := time.Now()
+	for _, rc := range s.recalculate {
+		constraint, err := recalculateConstraint(rc, now)
+		if err != nil {
+			logger.Logger.Error().Err(err).Msg("invalid webhook recalculate config")
+			continue
+		}
+
+		if _, err := s.statsService.Calculate(ctx, &CalculateRequest{
+			RepoID:     repoID,
+			Branch:     branch,
+			Constraint: constraint,
+		}); err != nil {
+			logger.Logger.Error().
+				Err(err).
+				Int64("repo_id", repoID).
+				Str("branch", branch).
+				Interface("constraint", constraint).
+				Msg("failed to submit webhook stats recalculation")
+		}
+	}
+}
+
+// recalculateConstraint 将配置转换为统计约束，相对时间范围按当前时间解析
+func recalculateConstraint(rc config.RecalculateConfig, now time.Time) (*models.StatsConstraint, error) {
+	if rc.Relative != "" {
+		return ResolveRelativeRange(rc.Relative, now)
+	}
+
+	return &models.StatsConstraint{
+		Type:  rc.Type,
+		From:  rc.From,
+		To:    rc.To,
+		Limit: rc.Limit,
+	}, nil
+}
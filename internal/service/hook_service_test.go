@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/webhook"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlePushMatchesRepoURLs(t *testing.T) {
+	store := &hookTestStore{repos: hookTestRepos{repos: []*models.Repository{
+		{ID: 1, URL: "https://github.com/octo-org/hello-world.git", Status: models.RepoStatusReady},
+		{ID: 2, URL: "git@github.com:octo-org/hello-world.git", Status: models.RepoStatusReady},
+		{ID: 3, URL: "ssh://git@github.com/octo-org/hello-world", Status: models.RepoStatusReady},
+		{ID: 4, URL: "https://gitea.example.com/gitea/webhooks.git", Status: models.RepoStatusReady},
+		{ID: 5, URL: "ssh://git@gitea.example.com:2222/gitea/webhooks.git", Status: models.RepoStatusReady},
+		{ID: 6, URL: "git@gitlab.example.com:mike/diaspora.git", Status: models.RepoStatusReady},
+		{ID: 7, URL: "https://gitlab.example.com/mike/diaspora", Status: models.RepoStatusCloning},
+		{ID: 8, URL: "https://github.com/octo-org/hello-world-fork.git", Status: models.RepoStatusReady},
+	}}}
+
+	tests := []struct {
+		name    string
+		urls    []string
+		want    []int64
+		skipped []int64
+	}{
+		{"https clone url", []string{"https://github.com/octo-org/hello-world.git"}, []int64{1, 2, 3}, nil},
+		{"scp-style ssh url", []string{"git@github.com:octo-org/hello-world.git"}, []int64{1, 2, 3}, nil},
+		{"web url without .git", []string{"https://github.com/octo-org/hello-world"}, []int64{1, 2, 3}, nil},
+		{"ssh url with port", []string{"ssh://git@gitea.example.com:2222/gitea/webhooks.git"}, []int64{5}, nil},
+		{"ssh port is part of host", []string{"https://gitea.example.com/gitea/webhooks"}, []int64{4}, nil},
+		{"any of several urls", []string{"https://gitlab.example.com/mike/diaspora.git", "git@gitlab.example.com:mike/diaspora.git"}, []int64{6, 7}, []int64{7}},
+		{"no match", []string{"https://github.com/octo-org/other.git"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHookService(store, worker.NewQueue(len(store.repos.repos), store), nil, config.InboundWebhookConfig{})
+
+			result, err := s.HandlePush(context.Background(), &webhook.PushEvent{
+				Provider: webhook.ProviderGitHub,
+				Ref:      "refs/heads/main",
+				Branch:   "main",
+				RepoURLs: tt.urls,
+			})
+			require.NoError(t, err)
+
+			var got, skipped []int64
+			for _, item := range result.Repos {
+				got = append(got, item.RepoID)
+				if item.Skipped != "" {
+					skipped = append(skipped, item.RepoID)
+					assert.Zero(t, item.PullTaskID)
+				} else {
+					assert.NotZero(t, item.PullTaskID)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.skipped, skipped)
+		})
+	}
+}
+
+func TestHandlePushWithoutURL(t *testing.T) {
+	store := &hookTestStore{}
+	s := NewHookService(store, worker.NewQueue(1, store), nil, config.InboundWebhookConfig{})
+
+	_, err := s.HandlePush(context.Background(), &webhook.PushEvent{Provider: webhook.ProviderGitHub})
+	assert.Error(t, err)
+}
+
+// hookTestStore 只实现HandlePush用到的仓库列表和任务创建
+type hookTestStore struct {
+	storage.Store
+	repos hookTestRepos
+	tasks hookTestTasks
+}
+
+func (s *hookTestStore) Repos() storage.RepoStore { return &s.repos }
+func (s *hookTestStore) Tasks() storage.TaskStore { return &s.tasks }
+
+type hookTestRepos struct {
+	storage.RepoStore
+	repos []*models.Repository
+}
+
+func (r *hookTestRepos) List(ctx context.Context, status string, page, pageSize int) ([]*models.Repository, int, error) {
+	start := min((page-1)*pageSize, len(r.repos))
+	end := min(start+pageSize, len(r.repos))
+	return r.repos[start:end], len(r.repos), nil
+}
+
+type hookTestTasks struct {
+	storage.TaskStore
+	nextID int64
+}
+
+func (t *hookTestTasks) FindExisting(ctx context.Context, repoID int64, taskType, params string) (*models.Task, error) {
+	return nil, nil
+}
+
+func (t *hookTestTasks) Create(ctx context.Context, task *models.Task) error {
+	t.nextID++
+	task.ID = t.nextID
+	return nil
+}
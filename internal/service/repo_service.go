@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -18,20 +17,25 @@ import (
 	"github.com/hanxuanyu/gitcodestatic/internal/worker"
 )
 
+// ErrInvalidRefQuery 引用查询参数无效
+var ErrInvalidRefQuery = errors.New("invalid ref query")
+
 // RepoService 仓库服务
 type RepoService struct {
 	store      storage.Store
 	queue      *worker.Queue
 	cacheDir   string
+	localRoots []string
 	gitManager git.Manager
 }
 
 // NewRepoService 创建仓库服务
-func NewRepoService(store storage.Store, queue *worker.Queue, cacheDir string, gitManager git.Manager) *RepoService {
+func NewRepoService(store storage.Store, queue *worker.Queue, cacheDir string, localRoots []string, gitManager git.Manager) *RepoService {
 	return &RepoService{
 		store:      store,
 		queue:      queue,
 		cacheDir:   cacheDir,
+		localRoots: localRoots,
 		gitManager: gitManager,
 	}
 }
@@ -39,14 +43,24 @@ func NewRepoService(store storage.Store, queue *worker.Queue, cacheDir string, g
 // AddReposRequest 批量添加仓库请求
 // RepoInput 仓库输入
 type RepoInput struct {
-	URL    string `json:"url"`
-	Branch string `json:"branch"`
+	URL           string   `json:"url"`
+	Branch        string   `json:"branch"`
+	Labels        []string `json:"labels,omitempty"`
+	CloneStrategy string   `json:"clone_strategy,omitempty"` // full/blobless/treeless/shallow，默认full
+	ShallowSince  string   `json:"shallow_since,omitempty"`  // clone_strategy=shallow 时必填，YYYY-MM-DD
 }
 
 type AddReposRequest struct {
-	Repos    []RepoInput `json:"repos"`
-	Username string      `json:"username,omitempty"` // 可选的认证信息
-	Password string      `json:"password,omitempty"` // 可选的认证信息
+	Repos        []RepoInput `json:"repos"`
+	CredentialID string      `json:"credential_id,omitempty"` // 使用已有凭据，优先于下列认证信息
+	Username     string      `json:"username,omitempty"`      // 可选的认证信息
+	Password     string      `json:"password,omitempty"`      // 可选的认证信息
+	Token        string      `json:"token,omitempty"`         // 可选的访问令牌，单独提供时作为Bearer令牌，与username一起提供时作为密码
+
+	SSHPrivateKey    string `json:"ssh_private_key,omitempty"`     // 可选的SSH私钥（PEM），用于 git@/ssh:// 仓库
+	SSHPassphrase    string `json:"ssh_passphrase,omitempty"`      // SSH私钥口令
+	SSHKnownHosts    string `json:"ssh_known_hosts,omitempty"`     // SSH主机公钥（known_hosts格式），提供时严格校验
+	SSHTrustNewHosts bool   `json:"ssh_trust_new_hosts,omitempty"` // 未提供known_hosts时首次连接接受未知主机密钥，默认只信任系统known_hosts
 }
 
 // AddReposResponse 批量添加仓库响应
@@ -79,20 +93,56 @@ func (s *RepoService) AddRepos(ctx context.Context, req *AddReposRequest) (*AddR
 
 	// 如果提供了认证信息，创建凭据
 	var credentialID *string
-	if req.Username != "" && req.Password != "" {
-		cred := &models.Credential{
+	var cred *models.Credential
+	if req.SSHPrivateKey != "" {
+		cred = &models.Credential{
+			ID:            generateCredentialID(),
+			Username:      req.Username,
+			PrivateKey:    req.SSHPrivateKey,
+			Passphrase:    req.SSHPassphrase,
+			KnownHosts:    req.SSHKnownHosts,
+			AuthType:      models.AuthTypeSSH,
+			TrustNewHosts: req.SSHTrustNewHosts,
+		}
+	} else if req.Token != "" {
+		cred = &models.Credential{
+			ID:       generateCredentialID(),
+			Username: req.Username,
+			Password: req.Token,
+			AuthType: models.AuthTypeToken,
+		}
+	} else if req.Username != "" && req.Password != "" {
+		cred = &models.Credential{
 			ID:       generateCredentialID(),
 			Username: req.Username,
 			Password: req.Password,
 			AuthType: models.AuthTypeBasic,
 		}
+	}
 
+	if req.CredentialID != "" {
+		existing, err := s.store.Credentials().GetByID(ctx, req.CredentialID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credential: %w", err)
+		}
+		if existing == nil {
+			return nil, ErrCredentialNotFound
+		}
+		cred = existing
+		credentialID = &existing.ID
+	} else if cred != nil {
+		// 随仓库提交的认证信息绑定到第一个远程仓库的主机
+		for _, repoInput := range req.Repos {
+			if cred.Host = git.RepoHost(repoInput.URL); cred.Host != "" {
+				break
+			}
+		}
+		// 凭据保存失败（如未配置加密密钥）时拒绝添加，避免仓库在没有凭据的情况下被克隆
 		if err := s.store.Credentials().Create(ctx, cred); err != nil {
-			logger.Logger.Warn().Err(err).Msg("failed to save credential, will continue without credentials")
-		} else {
-			credentialID = &cred.ID
-			logger.Logger.Info().Str("credential_id", cred.ID).Msg("credential created")
+			return nil, fmt.Errorf("failed to save credential: %w", err)
 		}
+		credentialID = &cred.ID
+		logger.Logger.Info().Str("credential_id", cred.ID).Msg("credential created")
 	}
 
 	for _, repoInput := range req.Repos {
@@ -111,6 +161,30 @@ func (s *RepoService) AddRepos(ctx context.Context, req *AddReposRequest) (*AddR
 			continue
 		}
 
+		// 凭据只能用于其绑定主机上的仓库
+		if cred != nil && !git.IsLocalURL(url) {
+			if err := git.CheckCredentialHost(cred, url); err != nil {
+				resp.Failed = append(resp.Failed, AddRepoFailure{
+					URL:   url,
+					Error: err.Error(),
+				})
+				continue
+			}
+		}
+
+		// 校验克隆策略
+		strategy := repoInput.CloneStrategy
+		if strategy == "" {
+			strategy = models.CloneStrategyFull
+		}
+		if err := ValidateCloneStrategy(strategy, repoInput.ShallowSince); err != nil {
+			resp.Failed = append(resp.Failed, AddRepoFailure{
+				URL:   url,
+				Error: err.Error(),
+			})
+			continue
+		}
+
 		// 检查是否已存在
 		existing, err := s.store.Repos().GetByURL(ctx, url)
 		if err != nil {
@@ -131,13 +205,28 @@ func (s *RepoService) AddRepos(ctx context.Context, req *AddReposRequest) (*AddR
 
 		// 创建仓库记录
 		repoName := extractRepoName(url)
-		localPath := filepath.Join(s.cacheDir, repoName)
+		localPath := git.WorkspacePath(s.cacheDir, url)
+
+		// 本地仓库原地分析，不复制到缓存目录
+		if git.IsLocalURL(url) {
+			localPath, err = git.CheckLocalPath(url, s.localRoots)
+			if err != nil {
+				resp.Failed = append(resp.Failed, AddRepoFailure{
+					URL:   url,
+					Error: err.Error(),
+				})
+				continue
+			}
+		}
 
 		repo := &models.Repository{
 			URL:           url,
 			Name:          repoName,
 			CurrentBranch: branch,
+			Labels:        normalizeLabels(repoInput.Labels),
 			LocalPath:     localPath,
+			CloneStrategy: strategy,
+			ShallowSince:  repoInput.ShallowSince,
 			Status:        models.RepoStatusPending,
 			CredentialID:  credentialID,
 		}
@@ -328,12 +417,233 @@ func (s *RepoService) GetBranches(ctx context.Context, repoID int64) ([]string,
 	return branches, nil
 }
 
+// ListRefsResponse 引用列表响应
+type ListRefsResponse struct {
+	DefaultBranch string       `json:"default_branch"`
+	DefaultSHA    string       `json:"default_sha,omitempty"`
+	Refs          []models.Ref `json:"refs"`
+	Count         int          `json:"count"`
+	// AheadBehindTruncated 引用数超过 git.MaxAheadBehindRefs，只有最近的引用带有领先/落后提交数
+	AheadBehindTruncated bool `json:"ahead_behind_truncated,omitempty"`
+}
+
+// ListRefs 获取仓库分支和标签，refType为空时返回全部类型
+//
+// withAheadBehind 为true时为每个引用计算相对默认分支（仓库当前统计分支）的领先/落后提交数。
+// 出于性能考虑最多只计算最近的 git.MaxAheadBehindRefs 个引用，超出时响应中
+// AheadBehindTruncated 为true。
+func (s *RepoService) ListRefs(ctx context.Context, repoID int64, refType string, withAheadBehind bool) (*ListRefsResponse, error) {
+	if refType != "" && refType != models.RefTypeBranch && refType != models.RefTypeRemote && refType != models.RefTypeTag {
+		return nil, fmt.Errorf("%w: type must be one of %s, %s, %s", ErrInvalidRefQuery, models.RefTypeBranch, models.RefTypeRemote, models.RefTypeTag)
+	}
+
+	repo, err := s.store.Repos().GetByID(ctx, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+	if repo == nil {
+		return nil, ErrRepoNotFound
+	}
+	if repo.Status != models.RepoStatusReady {
+		return nil, fmt.Errorf("%w, status: %s", ErrRepoNotReady, repo.Status)
+	}
+
+	resp := &ListRefsResponse{DefaultBranch: repo.CurrentBranch}
+	if withAheadBehind && repo.CurrentBranch != "" {
+		sha, err := s.gitManager.ResolveRef(ctx, repo.LocalPath, repo.CurrentBranch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve default branch %s: %w", repo.CurrentBranch, err)
+		}
+		resp.DefaultSHA = sha
+	}
+
+	refs, err := s.gitManager.ListRefs(ctx, repo.LocalPath, refType, resp.DefaultSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	resp.Refs = refs
+	resp.Count = len(refs)
+	resp.AheadBehindTruncated = resp.DefaultSHA != "" && len(refs) > git.MaxAheadBehindRefs
+
+	return resp, nil
+}
+
+// SetLabels 设置仓库标签
+func (s *RepoService) SetLabels(ctx context.Context, repoID int64, labels []string) (*models.Repository, error) {
+	repo, err := s.store.Repos().GetByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	repo.Labels = normalizeLabels(labels)
+	if err := s.store.Repos().Update(ctx, repo); err != nil {
+		return nil, fmt.Errorf("failed to update repository: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("repo_id", repoID).
+		Strs("labels", repo.Labels).
+		Msg("repository labels updated")
+
+	return repo, nil
+}
+
+// SetSyncInterval 设置仓库自动同步间隔（分钟），nil恢复使用全局配置，0关闭自动同步
+//
+// 清空下次同步时间，由同步调度器按新间隔重新计算。
+func (s *RepoService) SetSyncInterval(ctx context.Context, repoID int64, minutes *int) (*models.Repository, error) {
+	if minutes != nil && *minutes < 0 {
+		return nil, errors.New("interval_minutes must be non-negative")
+	}
+
+	repo, err := s.store.Repos().GetByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	repo.SyncInterval = minutes
+	repo.NextSyncAt = nil
+	if err := s.store.Repos().Update(ctx, repo); err != nil {
+		return nil, fmt.Errorf("failed to update repository: %w", err)
+	}
+
+	event := logger.Logger.Info().Int64("repo_id", repoID)
+	if minutes != nil {
+		event = event.Int("interval_minutes", *minutes)
+	}
+	event.Msg("repository sync interval updated")
+
+	return repo, nil
+}
+
+// SetCredential 为仓库绑定凭据，credentialID为空时解除绑定
+//
+// 新凭据在下次克隆/拉取时生效，仓库必须位于凭据绑定的主机上。
+func (s *RepoService) SetCredential(ctx context.Context, repoID int64, credentialID string) (*models.Repository, error) {
+	repo, err := s.store.Repos().GetByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return nil, errors.New("repository not found")
+	}
+
+	repo.CredentialID = nil
+	if credentialID != "" {
+		cred, err := s.store.Credentials().GetByID(ctx, credentialID)
+		if err != nil {
+			return nil, err
+		}
+		if cred == nil {
+			return nil, ErrCredentialNotFound
+		}
+		if err := git.CheckCredentialHost(cred, repo.URL); err != nil {
+			return nil, err
+		}
+		repo.CredentialID = &cred.ID
+	}
+
+	if err := s.store.Repos().Update(ctx, repo); err != nil {
+		return nil, fmt.Errorf("failed to update repository: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("repo_id", repoID).
+		Str("credential_id", credentialID).
+		Msg("repository credential updated")
+
+	return repo, nil
+}
+
+// listAllRepos 分页遍历获取全部仓库
+func listAllRepos(ctx context.Context, store storage.Store, status string) ([]*models.Repository, error) {
+	const pageSize = 100
+
+	all := make([]*models.Repository, 0)
+	for page := 1; ; page++ {
+		repos, total, err := store.Repos().List(ctx, status, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if len(repos) < pageSize || len(all) >= total {
+			break
+		}
+	}
+
+	return all, nil
+}
+
+// ValidateSSHKey 校验SSH私钥及口令是否可用
+func ValidateSSHKey(privateKey, passphrase string) error {
+	_, err := git.DecodeSSHKey(privateKey, passphrase)
+	return err
+}
+
+// ValidateCloneStrategy 校验克隆策略
+func ValidateCloneStrategy(strategy, shallowSince string) error {
+	switch strategy {
+	case models.CloneStrategyFull, models.CloneStrategyBlobless, models.CloneStrategyTreeless:
+		if shallowSince != "" {
+			return fmt.Errorf("shallow_since can only be used with clone_strategy %s", models.CloneStrategyShallow)
+		}
+	case models.CloneStrategyShallow:
+		if shallowSince == "" {
+			return fmt.Errorf("clone_strategy %s requires shallow_since", models.CloneStrategyShallow)
+		}
+		if _, err := git.ParseDate(shallowSince, false); err != nil {
+			return fmt.Errorf("invalid shallow_since: %w", err)
+		}
+	default:
+		return fmt.Errorf("clone_strategy must be one of %s, %s, %s, %s",
+			models.CloneStrategyFull, models.CloneStrategyBlobless, models.CloneStrategyTreeless, models.CloneStrategyShallow)
+	}
+
+	return nil
+}
+
+// normalizeLabels 标签去空白、去重
+func normalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	normalized := make([]string, 0, len(labels))
+
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+
+	return normalized
+}
+
+// hasLabel 判断仓库是否带有指定标签
+func hasLabel(repo *models.Repository, label string) bool {
+	for _, l := range repo.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
 // isValidGitURL 校验Git URL
 func isValidGitURL(url string) bool {
-	// 简单校验：https:// 或 git@ 开头
+	// 简单校验：https://、ssh://、git@ 开头，或本地仓库（file:// 和绝对路径，添加时再校验允许的根目录）
 	return strings.HasPrefix(url, "https://") ||
 		strings.HasPrefix(url, "http://") ||
-		strings.HasPrefix(url, "git@")
+		strings.HasPrefix(url, "ssh://") ||
+		strings.HasPrefix(url, "git@") ||
+		git.IsLocalURL(url)
 }
 
 // extractRepoName 从URL提取仓库名称
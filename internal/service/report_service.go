@@ -0,0 +1,498 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/cron"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
+)
+
+// ErrReportNotFound 报表不存在
+var ErrReportNotFound = errors.New("report not found")
+
+// ErrReportServiceStopped 服务正在停止，不再开始新的运行
+var ErrReportServiceStopped = errors.New("report service stopped")
+
+// reportPollInterval 等待聚合结果时的轮询间隔
+const reportPollInterval = 2 * time.Second
+
+// ReportService 定时报表服务
+//
+// 每次运行先拉取过期的仓库，再通过跨仓库聚合统计生成结果，运行记录中保存各仓库的统计缓存键。
+type ReportService struct {
+	store            storage.Store
+	queue            *worker.Queue
+	aggregateService *AggregateService
+	pullIfOlderThan  time.Duration
+
+	mu      sync.Mutex
+	running map[int64]bool
+
+	// 后台运行使用的context，Stop时取消并等待运行结束
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReportService 创建定时报表服务
+//
+// pullIfOlderThan 为运行前拉取仓库的阈值，上次拉取早于该时长的仓库先拉取；0表示每次都拉取。
+func NewReportService(store storage.Store, queue *worker.Queue, aggregateService *AggregateService, pullIfOlderThan time.Duration) *ReportService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ReportService{
+		store:            store,
+		queue:            queue,
+		aggregateService: aggregateService,
+		pullIfOlderThan:  pullIfOlderThan,
+		running:          make(map[int64]bool),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// Stop 取消进行中的运行并等待其记录结果，需在worker池停止之前调用
+func (s *ReportService) Stop() {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// ReportRequest 创建/更新报表请求
+type ReportRequest struct {
+	Name       string                  `json:"name"`
+	RepoIDs    []int64                 `json:"repo_ids,omitempty"` // 与label二选一
+	Label      string                  `json:"label,omitempty"`    // 与repo_ids二选一
+	Branch     string                  `json:"branch,omitempty"`   // 为空时使用各仓库当前分支
+	Constraint *models.StatsConstraint `json:"constraint,omitempty"`
+	Relative   string                  `json:"relative,omitempty"` // 与constraint二选一，如 previous_month
+	Cron       string                  `json:"cron"`
+	Enabled    *bool                   `json:"enabled,omitempty"` // 默认启用
+}
+
+// ListReports 获取报表列表
+func (s *ReportService) ListReports(ctx context.Context) ([]*models.Report, error) {
+	reports, err := s.store.Reports().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	return reports, nil
+}
+
+// GetReport 获取报表
+func (s *ReportService) GetReport(ctx context.Context, id int64) (*models.Report, error) {
+	report, err := s.store.Reports().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// CreateReport 创建报表
+func (s *ReportService) CreateReport(ctx context.Context, req *ReportRequest) (*models.Report, error) {
+	report := &models.Report{Enabled: true}
+	if err := applyReportRequest(report, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Reports().Create(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("report_id", report.ID).
+		Str("name", report.Name).
+		Str("cron", report.Cron).
+		Msg("report created")
+
+	return report, nil
+}
+
+// UpdateReport 更新报表，修改cron后按新表达式重新计算下次运行时间
+func (s *ReportService) UpdateReport(ctx context.Context, id int64, req *ReportRequest) (*models.Report, error) {
+	report, err := s.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyReportRequest(report, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Reports().Update(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to update report: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("report_id", report.ID).
+		Str("cron", report.Cron).
+		Bool("enabled", report.Enabled).
+		Msg("report updated")
+
+	return report, nil
+}
+
+// DeleteReport 删除报表及其运行记录
+func (s *ReportService) DeleteReport(ctx context.Context, id int64) error {
+	if _, err := s.GetReport(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.store.Reports().Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete report: %w", err)
+	}
+
+	logger.Logger.Info().Int64("report_id", id).Msg("report deleted")
+
+	return nil
+}
+
+// ListRuns 获取报表运行记录，按开始时间从新到旧
+func (s *ReportService) ListRuns(ctx context.Context, id int64, limit int) ([]*models.ReportRun, error) {
+	if _, err := s.GetReport(ctx, id); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.store.Reports().ListRuns(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report runs: %w", err)
+	}
+	return runs, nil
+}
+
+// RunReport 立即运行报表，不影响定时计划
+func (s *ReportService) RunReport(ctx context.Context, id int64) (*models.ReportRun, error) {
+	report, err := s.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.start(ctx, report, models.ReportTriggerManual, time.Now())
+}
+
+// RunDue 运行所有到期的报表，由调度器定期调用
+func (s *ReportService) RunDue(ctx context.Context, now time.Time) {
+	reports, err := s.store.Reports().List(ctx)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list reports")
+		return
+	}
+
+	for _, report := range reports {
+		if ctx.Err() != nil {
+			return
+		}
+		if !report.Enabled {
+			continue
+		}
+		if err := s.runIfDue(ctx, report, now); err != nil {
+			logger.Logger.Error().Err(err).Int64("report_id", report.ID).Msg("failed to run scheduled report")
+		}
+	}
+}
+
+// runIfDue 报表到期时更新计划时间并启动运行
+func (s *ReportService) runIfDue(ctx context.Context, report *models.Report, now time.Time) error {
+	schedule, err := cron.Parse(report.Cron)
+	if err != nil {
+		return err
+	}
+
+	due := report.NextRunAt != nil && !now.Before(*report.NextRunAt)
+	if report.NextRunAt != nil && !due {
+		return nil
+	}
+
+	next := schedule.Next(now)
+	report.NextRunAt = &next
+	if due {
+		report.LastRunAt = &now
+	}
+	if err := s.store.Reports().Update(ctx, report); err != nil {
+		return fmt.Errorf("failed to update report: %w", err)
+	}
+
+	if !due {
+		return nil
+	}
+
+	_, err = s.start(ctx, report, models.ReportTriggerSchedule, now)
+	return err
+}
+
+// start 创建运行记录并在后台执行，同一报表同时只有一次运行
+func (s *ReportService) start(ctx context.Context, report *models.Report, trigger string, now time.Time) (*models.ReportRun, error) {
+	constraint := report.Constraint
+	if report.Relative != "" {
+		var err error
+		if constraint, err = ResolveRelativeRange(report.Relative, now); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return nil, ErrReportServiceStopped
+	}
+	if s.running[report.ID] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("report %d is already running", report.ID)
+	}
+	s.running[report.ID] = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	run := &models.ReportRun{
+		ReportID:   report.ID,
+		Trigger:    trigger,
+		Status:     models.TaskStatusRunning,
+		Constraint: constraint,
+		StartedAt:  now,
+	}
+	if err := s.store.Reports().CreateRun(ctx, run); err != nil {
+		s.finish(report.ID)
+		s.wg.Done()
+		return nil, fmt.Errorf("failed to create report run: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("report_id", report.ID).
+		Int64("run_id", run.ID).
+		Str("trigger", trigger).
+		Interface("constraint", constraint).
+		Msg("report run started")
+
+	runCopy := *run
+	go s.execute(report, &runCopy)
+
+	return run, nil
+}
+
+// execute 拉取过期仓库、提交聚合统计并等待结果
+func (s *ReportService) execute(report *models.Report, run *models.ReportRun) {
+	defer s.wg.Done()
+	defer s.finish(report.ID)
+
+	ctx, cancel := context.WithTimeout(s.ctx, aggregateTimeout)
+	defer cancel()
+
+	err := s.produce(ctx, report, run)
+
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	run.Status = models.TaskStatusCompleted
+	if err != nil {
+		run.Status = models.TaskStatusFailed
+		run.Error = err.Error()
+		logger.Logger.Error().Err(err).Int64("report_id", report.ID).Int64("run_id", run.ID).Msg("report run failed")
+	} else {
+		logger.Logger.Info().
+			Int64("report_id", report.ID).
+			Int64("run_id", run.ID).
+			Str("aggregate_key", run.AggregateKey).
+			Msg("report run completed")
+	}
+
+	// 使用独立的context保存结果，超时的运行也能记录失败原因
+	if err := s.store.Reports().UpdateRun(context.Background(), run); err != nil {
+		logger.Logger.Error().Err(err).Int64("run_id", run.ID).Msg("failed to save report run")
+	}
+}
+
+// produce 执行一次报表运行
+func (s *ReportService) produce(ctx context.Context, report *models.Report, run *models.ReportRun) error {
+	req := &AggregateRequest{
+		RepoIDs:    report.RepoIDs,
+		Label:      report.Label,
+		Branch:     report.Branch,
+		Constraint: run.Constraint,
+	}
+
+	repos, err := s.aggregateService.resolveRepos(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := s.pullStale(ctx, repos); err != nil {
+		return err
+	}
+
+	resp, err := s.aggregateService.Submit(ctx, req)
+	if err != nil {
+		return err
+	}
+	run.AggregateKey = resp.AggregateKey
+
+	ticker := time.NewTicker(reportPollInterval)
+	defer ticker.Stop()
+
+	for resp.Status == models.TaskStatusRunning {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for aggregate %s: %w", resp.AggregateKey, ctx.Err())
+		}
+
+		if resp, err = s.aggregateService.Get(ctx, run.AggregateKey); err != nil {
+			return err
+		}
+	}
+
+	if resp.Status != models.TaskStatusCompleted {
+		return fmt.Errorf("aggregate %s failed: %s", resp.AggregateKey, resp.Error)
+	}
+	if resp.Result != nil {
+		run.Repos = resp.Result.Repos
+	}
+
+	return nil
+}
+
+// pullStale 拉取上次拉取早于阈值的仓库并等待完成
+func (s *ReportService) pullStale(ctx context.Context, repos []*models.Repository) error {
+	tasks := make([]*models.Task, 0)
+	for _, repo := range repos {
+		if repo.LastPullAt != nil && s.pullIfOlderThan > 0 && time.Since(*repo.LastPullAt) < s.pullIfOlderThan {
+			continue
+		}
+
+		task := &models.Task{
+			TaskType: models.TaskTypePull,
+			RepoID:   repo.ID,
+			Priority: 0,
+		}
+		if err := s.queue.Enqueue(ctx, task); err != nil {
+			return fmt.Errorf("failed to enqueue pull task for repository %d: %w", repo.ID, err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	for _, task := range tasks {
+		done, err := s.queue.Wait(ctx, task.ID)
+		if err != nil {
+			return fmt.Errorf("failed to wait for pull task %d: %w", task.ID, err)
+		}
+		if done.Status != models.TaskStatusCompleted {
+			return fmt.Errorf("pull task %d for repository %d ended with status %s", task.ID, task.RepoID, done.Status)
+		}
+	}
+
+	return nil
+}
+
+// finish 标记报表运行结束
+func (s *ReportService) finish(reportID int64) {
+	s.mu.Lock()
+	delete(s.running, reportID)
+	s.mu.Unlock()
+}
+
+// applyReportRequest 校验请求并写入报表
+func applyReportRequest(report *models.Report, req *ReportRequest, now time.Time) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if (len(req.RepoIDs) == 0) == (req.Label == "") {
+		return errors.New("exactly one of repo_ids or label is required")
+	}
+
+	if (req.Constraint == nil) == (req.Relative == "") {
+		return errors.New("exactly one of constraint or relative is required")
+	}
+	if req.Constraint != nil {
+		if err := ValidateStatsConstraint(req.Constraint); err != nil {
+			return err
+		}
+	} else if _, err := ResolveRelativeRange(req.Relative, now); err != nil {
+		return err
+	}
+
+	schedule, err := cron.Parse(req.Cron)
+	if err != nil {
+		return err
+	}
+
+	if req.Branch != "" {
+		if err := git.ValidateRef(req.Branch); err != nil {
+			return err
+		}
+	}
+
+	if report.Cron != req.Cron || report.NextRunAt == nil {
+		next := schedule.Next(now)
+		report.NextRunAt = &next
+	}
+
+	report.Name = name
+	report.RepoIDs = req.RepoIDs
+	report.Label = req.Label
+	report.Branch = req.Branch
+	report.Constraint = req.Constraint
+	report.Relative = req.Relative
+	report.Cron = req.Cron
+	if req.Enabled != nil {
+		report.Enabled = *req.Enabled
+	}
+
+	return nil
+}
+
+// ResolveRelativeRange 将相对时间范围解析为具体日期范围，按服务器时区计算
+//
+// 除 *_to_date 外都只包含完整的天，同一天内多次运行得到相同的缓存键。
+func ResolveRelativeRange(relative string, now time.Time) (*models.StatsConstraint, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var from, to time.Time
+	switch relative {
+	case models.RelativeLast7Days:
+		from, to = today.AddDate(0, 0, -7), today.AddDate(0, 0, -1)
+	case models.RelativeLast30Days:
+		from, to = today.AddDate(0, 0, -30), today.AddDate(0, 0, -1)
+	case models.RelativeWeekToDate:
+		from, to = startOfWeek(today), today
+	case models.RelativeMonthToDate:
+		from, to = today.AddDate(0, 0, 1-today.Day()), today
+	case models.RelativePreviousWeek:
+		from = startOfWeek(today).AddDate(0, 0, -7)
+		to = from.AddDate(0, 0, 6)
+	case models.RelativePreviousMonth:
+		thisMonth := today.AddDate(0, 0, 1-today.Day())
+		from, to = thisMonth.AddDate(0, -1, 0), thisMonth.AddDate(0, 0, -1)
+	case models.RelativePreviousQuarter:
+		thisQuarter := time.Date(today.Year(), today.Month()-(today.Month()-1)%3, 1, 0, 0, 0, 0, today.Location())
+		from, to = thisQuarter.AddDate(0, -3, 0), thisQuarter.AddDate(0, 0, -1)
+	case models.RelativePreviousYear:
+		from = time.Date(today.Year()-1, 1, 1, 0, 0, 0, 0, today.Location())
+		to = time.Date(today.Year()-1, 12, 31, 0, 0, 0, 0, today.Location())
+	default:
+		return nil, fmt.Errorf("unsupported relative range %q", relative)
+	}
+
+	return &models.StatsConstraint{
+		Type: models.ConstraintTypeDateRange,
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+	}, nil
+}
+
+// startOfWeek 返回所在周的周一
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/hanxuanyu/gitcodestatic/internal/git"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/stats"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/teams"
 	"github.com/hanxuanyu/gitcodestatic/internal/worker"
 )
 
@@ -20,15 +22,17 @@ type StatsService struct {
 	queue      *worker.Queue
 	cache      *cache.FileCache
 	gitManager git.Manager
+	teams      *teams.Registry
 }
 
 // NewStatsService 创建统计服务
-func NewStatsService(store storage.Store, queue *worker.Queue, fileCache *cache.FileCache, gitManager git.Manager) *StatsService {
+func NewStatsService(store storage.Store, queue *worker.Queue, fileCache *cache.FileCache, gitManager git.Manager, teamRegistry *teams.Registry) *StatsService {
 	return &StatsService{
 		store:      store,
 		queue:      queue,
 		cache:      fileCache,
 		gitManager: gitManager,
+		teams:      teamRegistry,
 	}
 }
 
@@ -56,6 +60,17 @@ func (s *StatsService) Calculate(ctx context.Context, req *CalculateRequest) (*m
 		return nil, errors.New("repository is not ready")
 	}
 
+	// 提前校验分支可解析（支持本地分支、标签和 origin/ 远程分支）
+	commitHash, err := s.gitManager.ResolveRef(ctx, repo.LocalPath, req.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve branch %s: %w", req.Branch, err)
+	}
+
+	// 浅克隆仓库不能统计边界之前的历史
+	if err := s.checkShallowBoundary(ctx, repo, commitHash, req.Constraint); err != nil {
+		return nil, err
+	}
+
 	// 创建统计任务
 	params := models.TaskParameters{
 		Branch:     req.Branch,
@@ -83,6 +98,39 @@ func (s *StatsService) Calculate(ctx context.Context, req *CalculateRequest) (*m
 	return task, nil
 }
 
+// checkShallowBoundary 拒绝超出浅克隆历史边界的统计约束，commitHash为已解析的统计起点
+func (s *StatsService) checkShallowBoundary(ctx context.Context, repo *models.Repository, commitHash string, constraint *models.StatsConstraint) error {
+	if repo.CloneStrategy != models.CloneStrategyShallow || repo.ShallowSince == "" {
+		return nil
+	}
+
+	switch constraint.Type {
+	case models.ConstraintTypeDateRange:
+		from, err := git.ParseDate(constraint.From, false)
+		if err != nil {
+			return err
+		}
+		since, err := git.ParseDate(repo.ShallowSince, false)
+		if err != nil {
+			return fmt.Errorf("invalid shallow_since on repository: %w", err)
+		}
+		if from.Before(since) {
+			return fmt.Errorf("date range starts before shallow clone boundary %s", repo.ShallowSince)
+		}
+	case models.ConstraintTypeCommitLimit:
+		count, err := s.gitManager.CountCommits(ctx, repo.LocalPath, commitHash, "")
+		if err != nil {
+			return fmt.Errorf("failed to count commits: %w", err)
+		}
+		if count < constraint.Limit {
+			return fmt.Errorf("commit limit %d exceeds %d commits available since shallow clone boundary %s",
+				constraint.Limit, count, repo.ShallowSince)
+		}
+	}
+
+	return nil
+}
+
 // QueryResultRequest 查询统计结果请求
 type QueryResultRequest struct {
 	RepoID         int64  `json:"repo_id"`
@@ -116,14 +164,113 @@ func (s *StatsService) QueryResult(ctx context.Context, req *QueryResultRequest)
 		constraint.Limit = req.Limit
 	}
 
-	// 获取当前HEAD commit hash
-	commitHash, err := s.gitManager.GetHeadCommitHash(ctx, repo.LocalPath)
+	result, err := s.lookupCache(ctx, repo, req.Branch, constraint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get HEAD commit hash: %w", err)
+		return nil, err
+	}
+
+	if result != nil {
+		return result, nil
+	}
+
+	// 缓存未命中
+	return nil, errors.New("statistics not found, please submit calculation task first")
+}
+
+// CompareRequest 周期对比请求
+type CompareRequest struct {
+	RepoID int64                   `json:"repo_id"`
+	Branch string                  `json:"branch"`
+	Base   *models.StatsConstraint `json:"base"`   // 基准周期，如上月
+	Target *models.StatsConstraint `json:"target"` // 对比周期，如本月
+}
+
+// CompareResponse 周期对比响应
+type CompareResponse struct {
+	Status     string                  `json:"status"`             // running/completed
+	TaskIDs    []int64                 `json:"task_ids,omitempty"` // 缓存缺失时提交的统计任务
+	Base       *models.StatsResult     `json:"base,omitempty"`
+	Target     *models.StatsResult     `json:"target,omitempty"`
+	Comparison *models.StatsComparison `json:"comparison,omitempty"`
+}
+
+// Compare 对比同一仓库分支两个周期的统计结果
+//
+// 两侧结果优先从缓存读取；缺失的一侧会提交统计任务，待任务完成后再次请求即可得到对比结果。
+func (s *StatsService) Compare(ctx context.Context, req *CompareRequest) (*CompareResponse, error) {
+	if err := ValidateStatsConstraint(req.Base); err != nil {
+		return nil, fmt.Errorf("invalid base constraint: %w", err)
+	}
+	if err := ValidateStatsConstraint(req.Target); err != nil {
+		return nil, fmt.Errorf("invalid target constraint: %w", err)
+	}
+
+	repo, err := s.store.Repos().GetByID(ctx, req.RepoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.Status != models.RepoStatusReady {
+		return nil, errors.New("repository is not ready")
+	}
+
+	resp := &CompareResponse{
+		Status:  models.TaskStatusCompleted,
+		TaskIDs: make([]int64, 0),
+	}
+
+	sides := []struct {
+		constraint *models.StatsConstraint
+		result     **models.StatsResult
+	}{
+		{req.Base, &resp.Base},
+		{req.Target, &resp.Target},
+	}
+	for _, side := range sides {
+		result, err := s.lookupCache(ctx, repo, req.Branch, side.constraint)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			*side.result = result
+			continue
+		}
+
+		task, err := s.Calculate(ctx, &CalculateRequest{
+			RepoID:     req.RepoID,
+			Branch:     req.Branch,
+			Constraint: side.constraint,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to submit stats task: %w", err)
+		}
+		resp.Status = models.TaskStatusRunning
+		resp.TaskIDs = append(resp.TaskIDs, task.ID)
+	}
+
+	if resp.Status == models.TaskStatusCompleted {
+		resp.Comparison = stats.Compare(resp.Base.Statistics, resp.Target.Statistics)
+	}
+
+	return resp, nil
+}
+
+// lookupCache 查询仓库分支在指定约束下的缓存结果，未命中时返回nil
+func (s *StatsService) lookupCache(ctx context.Context, repo *models.Repository, branch string, constraint *models.StatsConstraint) (*models.StatsResult, error) {
+	// 解析分支对应的commit hash
+	commitHash, err := s.gitManager.ResolveRef(ctx, repo.LocalPath, branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve branch %s: %w", branch, err)
+	}
+
+	// 团队映射版本
+	mapper, err := s.teams.Mapper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team mapping: %w", err)
 	}
 
 	// 生成缓存键
-	cacheKey := cache.GenerateCacheKey(req.RepoID, req.Branch, constraint, commitHash)
+	cacheKey := cache.GenerateCacheKey(repo.ID, branch, constraint, commitHash, mapper.Version())
 
 	// 查询缓存
 	result, err := s.cache.Get(ctx, cacheKey)
@@ -131,12 +278,7 @@ func (s *StatsService) QueryResult(ctx context.Context, req *QueryResultRequest)
 		logger.Logger.Warn().Err(err).Str("cache_key", cacheKey).Msg("failed to get cache")
 	}
 
-	if result != nil {
-		return result, nil
-	}
-
-	// 缓存未命中
-	return nil, errors.New("statistics not found, please submit calculation task first")
+	return result, nil
 }
 
 // CountCommitsRequest 统计提交次数请求
@@ -5,17 +5,20 @@ import (
 
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/worker"
 )
 
 // TaskService 任务服务
 type TaskService struct {
 	store storage.Store
+	queue *worker.Queue
 }
 
 // NewTaskService 创建任务服务
-func NewTaskService(store storage.Store) *TaskService {
+func NewTaskService(store storage.Store, queue *worker.Queue) *TaskService {
 	return &TaskService{
 		store: store,
+		queue: queue,
 	}
 }
 
@@ -29,7 +32,9 @@ func (s *TaskService) ListTasks(ctx context.Context, repoID int64, status string
 	return s.store.Tasks().List(ctx, repoID, status, page, pageSize)
 }
 
-// CancelTask 取消任务
-func (s *TaskService) CancelTask(ctx context.Context, id int64) error {
-	return s.store.Tasks().Cancel(ctx, id)
+// CancelTask 取消任务，待处理的任务从队列移除，运行中的任务终止执行
+//
+// 任务不存在返回 worker.ErrTaskNotFound，已结束返回 worker.ErrTaskFinished。
+func (s *TaskService) CancelTask(ctx context.Context, id int64) (*models.Task, error) {
+	return s.queue.Cancel(ctx, id)
 }
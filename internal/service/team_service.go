@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/teams"
+)
+
+// TeamService 团队服务
+type TeamService struct {
+	store    storage.Store
+	registry *teams.Registry
+}
+
+// NewTeamService 创建团队服务
+func NewTeamService(store storage.Store, registry *teams.Registry) *TeamService {
+	return &TeamService{
+		store:    store,
+		registry: registry,
+	}
+}
+
+// TeamRequest 创建/更新团队请求
+type TeamRequest struct {
+	Name     string   `json:"name"`
+	Emails   []string `json:"emails"`
+	Patterns []string `json:"patterns"`
+}
+
+// ListTeamsResponse 团队列表响应
+type ListTeamsResponse struct {
+	Teams   []*models.Team `json:"teams"`
+	Version string         `json:"version"` // 当前映射版本
+}
+
+// ListTeams 获取团队列表
+func (s *TeamService) ListTeams(ctx context.Context) (*ListTeamsResponse, error) {
+	list, err := s.registry.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, err := teams.NewMapper(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListTeamsResponse{
+		Teams:   list,
+		Version: mapper.Version(),
+	}, nil
+}
+
+// CreateTeam 创建团队
+func (s *TeamService) CreateTeam(ctx context.Context, req *TeamRequest) (*models.Team, error) {
+	team := &models.Team{
+		Name:     strings.TrimSpace(req.Name),
+		Emails:   req.Emails,
+		Patterns: req.Patterns,
+		Source:   models.TeamSourceAPI,
+	}
+
+	if err := s.validateTeam(ctx, team, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Teams().Create(ctx, team); err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("team_id", team.ID).
+		Str("name", team.Name).
+		Msg("team created")
+
+	return team, nil
+}
+
+// UpdateTeam 更新团队
+func (s *TeamService) UpdateTeam(ctx context.Context, id int64, req *TeamRequest) (*models.Team, error) {
+	team, err := s.store.Teams().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, errors.New("team not found")
+	}
+
+	team.Name = strings.TrimSpace(req.Name)
+	team.Emails = req.Emails
+	team.Patterns = req.Patterns
+	team.Source = models.TeamSourceAPI
+
+	if err := s.validateTeam(ctx, team, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Teams().Update(ctx, team); err != nil {
+		return nil, fmt.Errorf("failed to update team: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("team_id", team.ID).
+		Str("name", team.Name).
+		Msg("team updated")
+
+	return team, nil
+}
+
+// DeleteTeam 删除团队
+func (s *TeamService) DeleteTeam(ctx context.Context, id int64) error {
+	return s.store.Teams().Delete(ctx, id)
+}
+
+// validateTeam 校验团队定义，名称不能与其他团队重复
+func (s *TeamService) validateTeam(ctx context.Context, team *models.Team, selfID int64) error {
+	if err := teams.Validate(team); err != nil {
+		return err
+	}
+
+	existing, err := s.registry.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range existing {
+		if other.Source == models.TeamSourceAPI && other.ID == selfID {
+			continue
+		}
+		if strings.EqualFold(other.Name, team.Name) {
+			return fmt.Errorf("team %s already exists", team.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/webhook"
+)
+
+var (
+	// ErrWebhookNotFound 订阅不存在
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDispatchDisabled 未启用事件投递
+	ErrWebhookDispatchDisabled = errors.New("outbound webhooks are disabled")
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// WebhookService 事件订阅服务
+type WebhookService struct {
+	store      storage.Store
+	dispatcher *webhook.Dispatcher
+}
+
+// NewWebhookService 创建事件订阅服务，dispatcher为nil表示未启用投递
+func NewWebhookService(store storage.Store, dispatcher *webhook.Dispatcher) *WebhookService {
+	return &WebhookService{
+		store:      store,
+		dispatcher: dispatcher,
+	}
+}
+
+// WebhookRequest 创建/更新订阅请求
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`                // 订阅的事件，"*" 表示全部
+	Secret      string   `json:"secret,omitempty"`      // 签名密钥，创建时为空则自动生成；更新时为空保持原值
+	Description string   `json:"description,omitempty"` // 备注
+	Enabled     *bool    `json:"enabled,omitempty"`     // 默认启用
+}
+
+// CreateWebhookResponse 创建订阅响应，签名密钥只在此返回
+type CreateWebhookResponse struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// ListWebhooks 获取订阅列表
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	subs, err := s.store.Webhooks().ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return subs, nil
+}
+
+// GetWebhook 获取订阅
+func (s *WebhookService) GetWebhook(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	sub, err := s.store.Webhooks().GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, nil
+}
+
+// CreateWebhook 创建订阅
+func (s *WebhookService) CreateWebhook(ctx context.Context, req *WebhookRequest) (*CreateWebhookResponse, error) {
+	sub := &models.WebhookSubscription{Enabled: true}
+	if err := applyWebhookRequest(sub, req); err != nil {
+		return nil, err
+	}
+
+	if sub.Secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		sub.Secret = hex.EncodeToString(buf)
+	}
+
+	if err := s.store.Webhooks().CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("subscription_id", sub.ID).
+		Str("url", sub.URL).
+		Strs("events", sub.Events).
+		Msg("webhook created")
+
+	return &CreateWebhookResponse{WebhookSubscription: sub, Secret: sub.Secret}, nil
+}
+
+// UpdateWebhook 更新订阅，secret非空时轮换签名密钥
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id int64, req *WebhookRequest) (*models.WebhookSubscription, error) {
+	sub, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyWebhookRequest(sub, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Webhooks().UpdateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	logger.Logger.Info().
+		Int64("subscription_id", sub.ID).
+		Strs("events", sub.Events).
+		Bool("enabled", sub.Enabled).
+		Msg("webhook updated")
+
+	return sub, nil
+}
+
+// DeleteWebhook 删除订阅及其投递记录
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.store.Webhooks().DeleteSubscription(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	logger.Logger.Info().Int64("subscription_id", id).Msg("webhook deleted")
+
+	return nil
+}
+
+// ListDeliveries 按创建时间从新到旧返回订阅的投递记录，status为空时返回全部
+func (s *WebhookService) ListDeliveries(ctx context.Context, id int64, status string, limit int) ([]*models.WebhookDelivery, error) {
+	switch status {
+	case "", models.DeliveryStatusPending, models.DeliveryStatusSuccess, models.DeliveryStatusFailed:
+	default:
+		return nil, fmt.Errorf("invalid status: %s", status)
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		return nil, fmt.Errorf("limit must be at most %d", maxDeliveriesLimit)
+	}
+
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.store.Webhooks().ListDeliveries(ctx, id, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// PingWebhook 立即向订阅投递一次 ping 事件，返回投递记录
+func (s *WebhookService) PingWebhook(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	if s.dispatcher == nil {
+		return nil, ErrWebhookDispatchDisabled
+	}
+
+	sub, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dispatcher.Ping(ctx, sub)
+}
+
+// applyWebhookRequest 校验请求并写入订阅
+func applyWebhookRequest(sub *models.WebhookSubscription, req *WebhookRequest) error {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	if len(req.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	events := make([]string, 0, len(req.Events))
+	seen := make(map[string]bool, len(req.Events))
+	for _, event := range req.Events {
+		event = strings.TrimSpace(event)
+		if !isWebhookEvent(event) {
+			return fmt.Errorf("unsupported event %q, must be one of %s or *", event, strings.Join(models.WebhookEvents, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+
+	sub.URL = u.String()
+	sub.Events = events
+	sub.Description = strings.TrimSpace(req.Description)
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+
+	return nil
+}
+
+// isWebhookEvent 是否为可订阅的事件
+func isWebhookEvent(event string) bool {
+	if event == models.EventAll {
+		return true
+	}
+	for _, e := range models.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+// MigrateWorkspaceLayout 将旧布局（cache_dir/<仓库名>）的克隆迁移到按主机、路径和hash划分的新布局
+//
+// 旧布局下同名仓库共用一个目录，目录中origin与仓库地址不一致的仓库无法确定原内容，
+// 标记为失败并保留原路径，提示重置重新克隆。本地仓库原地分析，不参与迁移。
+// 返回成功迁移和迁移失败的仓库数。
+func MigrateWorkspaceLayout(ctx context.Context, store storage.Store, cacheDir string) (migrated, failed int, err error) {
+	repos, err := listAllRepos(ctx, store, "")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	for _, repo := range repos {
+		if git.IsLocalURL(repo.URL) {
+			continue
+		}
+
+		target := git.WorkspacePath(cacheDir, repo.URL)
+		if repo.LocalPath == target {
+			continue
+		}
+
+		oldPath := repo.LocalPath
+		if err := moveClone(repo, oldPath, target); err != nil {
+			errMsg := fmt.Sprintf("workspace migration: %v, reset the repository to re-clone", err)
+			repo.Status = models.RepoStatusFailed
+			repo.ErrorMessage = &errMsg
+			if err := store.Repos().Update(ctx, repo); err != nil {
+				return migrated, failed, fmt.Errorf("failed to update repository %d: %w", repo.ID, err)
+			}
+			failed++
+
+			logger.Logger.Warn().
+				Err(err).
+				Int64("repo_id", repo.ID).
+				Str("old_path", oldPath).
+				Msg("failed to migrate repository clone")
+			continue
+		}
+
+		repo.LocalPath = target
+		if err := store.Repos().Update(ctx, repo); err != nil {
+			return migrated, failed, fmt.Errorf("failed to update repository %d: %w", repo.ID, err)
+		}
+		migrated++
+
+		logger.Logger.Info().
+			Int64("repo_id", repo.ID).
+			Str("old_path", oldPath).
+			Str("new_path", target).
+			Msg("repository clone migrated")
+	}
+
+	return migrated, failed, nil
+}
+
+// moveClone 将克隆目录移动到新路径，旧目录不存在（尚未克隆）时无需移动
+func moveClone(repo *models.Repository, oldPath, target string) error {
+	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+		if repo.Status == models.RepoStatusReady {
+			return fmt.Errorf("clone %s not found", oldPath)
+		}
+		return nil
+	}
+
+	// 旧目录可能属于另一个同名仓库
+	originURL, err := git.RemoteOriginURL(oldPath)
+	if err != nil {
+		return err
+	}
+	if git.NormalizeRepoURL(originURL) != git.NormalizeRepoURL(repo.URL) {
+		return fmt.Errorf("clone %s belongs to %s", oldPath, originURL)
+	}
+
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("target %s already exists", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(oldPath, target); err != nil {
+		return fmt.Errorf("failed to move clone: %w", err)
+	}
+
+	return nil
+}
+
+// ConvertWorkingCopies 将旧版本克隆的完整工作副本原地转换为裸镜像
+//
+// 本地仓库原地分析，保持原样。转换失败的仓库标记为失败并提示重置重新克隆。
+// 返回转换的仓库数。
+func ConvertWorkingCopies(ctx context.Context, store storage.Store) (int, error) {
+	repos, err := listAllRepos(ctx, store, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	converted := 0
+	for _, repo := range repos {
+		if git.IsLocalURL(repo.URL) || repo.Status == models.RepoStatusFailed {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(repo.LocalPath, ".git")); err != nil {
+			continue
+		}
+
+		ok, err := git.ConvertToBare(repo.LocalPath)
+		if err != nil {
+			errMsg := fmt.Sprintf("bare mirror conversion: %v, reset the repository to re-clone", err)
+			repo.Status = models.RepoStatusFailed
+			repo.ErrorMessage = &errMsg
+			if err := store.Repos().Update(ctx, repo); err != nil {
+				return converted, fmt.Errorf("failed to update repository %d: %w", repo.ID, err)
+			}
+
+			logger.Logger.Warn().
+				Err(err).
+				Int64("repo_id", repo.ID).
+				Str("local_path", repo.LocalPath).
+				Msg("failed to convert working copy to bare mirror")
+			continue
+		}
+		if !ok {
+			continue
+		}
+		converted++
+
+		logger.Logger.Info().
+			Int64("repo_id", repo.ID).
+			Str("local_path", repo.LocalPath).
+			Msg("working copy converted to bare mirror")
+	}
+
+	return converted, nil
+}
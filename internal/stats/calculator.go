@@ -2,32 +2,118 @@ package stats
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/progress"
 )
 
+// LogReader 提交日志读取接口
+//
+// 输出格式与 git log --no-merges --numstat --pretty=format:COMMIT:%H|AUTHOR:%an|EMAIL:%ae|DATE:%ai 一致，
+// 按提交时间从新到旧排列。
+type LogReader interface {
+	ReadLog(ctx context.Context, localPath, rev string, constraint *models.StatsConstraint) (string, error)
+
+	// ListCommits 按条件列出提交及其numstat，用于逐条核对统计结果
+	ListCommits(ctx context.Context, localPath string, query *CommitQuery) ([]models.Commit, error)
+
+	// GetCommit 获取单个提交的元数据、trailer和numstat
+	GetCommit(ctx context.Context, localPath, sha string) (*models.CommitDetail, error)
+
+	// ReadDiff 分页读取提交相对第一个父提交的unified diff
+	ReadDiff(ctx context.Context, localPath, sha string, parents []string, opts *DiffOptions) (*models.CommitDiff, error)
+}
+
+// PhaseReadingLog 读取提交日志阶段的进度名
+const PhaseReadingLog = "reading_log"
+
 // Calculator 统计计算器
 type Calculator struct {
-	gitPath string
+	reader LogReader
 }
 
-// NewCalculator 创建统计计算器
+// NewCalculator 创建基于git命令的统计计算器
 func NewCalculator(gitPath string) *Calculator {
+	return NewCalculatorWithReader(NewCmdLogReader(gitPath))
+}
+
+// NewCalculatorWithReader 使用指定日志读取器创建统计计算器
+func NewCalculatorWithReader(reader LogReader) *Calculator {
+	return &Calculator{reader: reader}
+}
+
+// Calculate 计算统计数据
+//
+// rev 可以是分支名或commit hash；调用方应传入已解析的commit hash，使统计结果与缓存键一致。
+func (c *Calculator) Calculate(ctx context.Context, localPath, rev string, constraint *models.StatsConstraint) (*models.Statistics, error) {
+	output, err := c.reader.ReadLog(ctx, localPath, rev, constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析输出
+	stats, err := c.parseGitLog(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse git log: %w", err)
+	}
+
+	// 填充摘要信息
+	stats.Summary.TotalContributors = len(stats.ByContributor)
+	if constraint != nil {
+		if constraint.Type == models.ConstraintTypeDateRange {
+			stats.Summary.DateRange = &models.DateRange{
+				From: constraint.From,
+				To:   constraint.To,
+			}
+		} else if constraint.Type == models.ConstraintTypeCommitLimit {
+			stats.Summary.CommitLimit = &constraint.Limit
+		}
+	}
+
+	return stats, nil
+}
+
+// CmdLogReader 基于git命令的日志读取器
+type CmdLogReader struct {
+	gitPath string
+}
+
+// NewCmdLogReader 创建基于git命令的日志读取器
+func NewCmdLogReader(gitPath string) *CmdLogReader {
 	if gitPath == "" {
 		gitPath = "git"
 	}
-	return &Calculator{gitPath: gitPath}
+	return &CmdLogReader{gitPath: gitPath}
 }
 
-// Calculate 计算统计数据
-func (c *Calculator) Calculate(ctx context.Context, localPath, branch string, constraint *models.StatsConstraint) (*models.Statistics, error) {
+// command 创建读取类git命令，返回的清理函数须在命令结束后执行
+//
+// 部分克隆读取numstat、show和diff时git会按需从远程拉取缺失的对象，
+// 这里使用上下文中的仓库凭据认证并禁止交互式提示。
+func (r *CmdLogReader) command(ctx context.Context, args ...string) (*exec.Cmd, func(), error) {
+	env, cleanup, err := git.CommandEnv(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, r.gitPath, args...)
+	cmd.Env = env
+	return cmd, cleanup, nil
+}
+
+// ReadLog 运行git log读取提交日志
+func (r *CmdLogReader) ReadLog(ctx context.Context, localPath, rev string, constraint *models.StatsConstraint) (string, error) {
 	// 构建git log命令
 	args := []string{
 		"-C", localPath,
@@ -38,53 +124,125 @@ func (c *Calculator) Calculate(ctx context.Context, localPath, branch string, co
 	}
 
 	// 添加约束条件
-	if constraint != nil {
-		if constraint.Type == models.ConstraintTypeDateRange {
-			if constraint.From != "" {
-				args = append(args, "--since="+constraint.From)
-			}
-			if constraint.To != "" {
-				args = append(args, "--until="+constraint.To)
-			}
-		} else if constraint.Type == models.ConstraintTypeCommitLimit {
-			args = append(args, "-n", strconv.Itoa(constraint.Limit))
-		}
+	constraintArgs, err := logConstraintArgs(constraint)
+	if err != nil {
+		return "", err
 	}
-
-	args = append(args, branch)
+	args = append(args, constraintArgs...)
+	args = append(args, rev, "--")
 
 	logger.Logger.Debug().
 		Str("local_path", localPath).
-		Str("branch", branch).
+		Str("rev", rev).
 		Interface("constraint", constraint).
 		Msg("running git log")
 
-	cmd := exec.CommandContext(ctx, c.gitPath, args...)
-	output, err := cmd.Output()
+	cmd, cleanup, err := r.command(ctx, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to run git log: %w", err)
+		return "", err
 	}
+	defer cleanup()
 
-	// 解析输出
-	stats, err := c.parseGitLog(string(output))
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if progress.Enabled(ctx) {
+		cmd.Stdout = io.MultiWriter(&output, &commitCounter{ctx: ctx, total: r.countCommits(ctx, localPath, rev, constraint)})
+	}
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	return output.String(), nil
+}
+
+// countCommits 统计约束范围内的非合并提交数，用于计算进度，失败时返回0
+func (r *CmdLogReader) countCommits(ctx context.Context, localPath, rev string, constraint *models.StatsConstraint) int {
+	constraintArgs, err := logConstraintArgs(constraint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse git log: %w", err)
+		return 0
 	}
+	args := []string{"-C", localPath, "rev-list", "--count", "--no-merges"}
+	args = append(args, constraintArgs...)
+	args = append(args, rev, "--")
 
-	// 填充摘要信息
-	stats.Summary.TotalContributors = len(stats.ByContributor)
+	cmd, cleanup, err := r.command(ctx, args...)
+	if err != nil {
+		return 0
+	}
+	defer cleanup()
+
+	output, err := cmd.Output()
+	if err != nil {
+		logger.Logger.Warn().Err(err).Str("local_path", localPath).Msg("failed to count commits for progress")
+		return 0
+	}
+
+	total, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	return total
+}
+
+// logConstraintArgs 将统计约束转换为git log/rev-list参数
+//
+// 日期与go-git后端一样经 git.ParseDate 解析后以RFC3339传给git：只有日期的 to 表示当天结束，
+// 直接传 --until=YYYY-MM-DD 时git会按当前时刻截止，两个后端结果不同却共用同一缓存键。
+func logConstraintArgs(constraint *models.StatsConstraint) ([]string, error) {
+	var args []string
 	if constraint != nil {
 		if constraint.Type == models.ConstraintTypeDateRange {
-			stats.Summary.DateRange = &models.DateRange{
-				From: constraint.From,
-				To:   constraint.To,
+			if constraint.From != "" {
+				since, err := git.ParseDate(constraint.From, false)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, "--since="+since.Format(time.RFC3339))
+			}
+			if constraint.To != "" {
+				until, err := git.ParseDate(constraint.To, true)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, "--until="+until.Format(time.RFC3339))
 			}
 		} else if constraint.Type == models.ConstraintTypeCommitLimit {
-			stats.Summary.CommitLimit = &constraint.Limit
+			args = append(args, "-n", strconv.Itoa(constraint.Limit))
 		}
 	}
+	return args, nil
+}
 
-	return stats, nil
+// commitCounter 统计git log输出中的提交行并上报读取进度
+type commitCounter struct {
+	ctx   context.Context
+	total int
+	count int
+	line  []byte
+}
+
+// Write 实现io.Writer，只检查每行开头
+func (c *commitCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' {
+			if bytes.HasPrefix(c.line, []byte("COMMIT:")) {
+				c.count++
+				reportCommits(c.ctx, c.count, c.total)
+			}
+			c.line = c.line[:0]
+			continue
+		}
+		if len(c.line) < len("COMMIT:") {
+			c.line = append(c.line, b)
+		}
+	}
+	return len(p), nil
+}
+
+// reportCommits 上报已读取的提交数，total为0表示总数未知
+func reportCommits(ctx context.Context, count, total int) {
+	if total <= 0 {
+		progress.Report(ctx, PhaseReadingLog, 0, fmt.Sprintf("%d commits", count))
+		return
+	}
+	progress.Report(ctx, PhaseReadingLog, min(count*100/total, 100), fmt.Sprintf("%d/%d commits", count, total))
 }
 
 // parseGitLog 解析git log输出
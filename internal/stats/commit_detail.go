@@ -0,0 +1,301 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// emptyTreeHash 空树，根提交与其比较得到完整diff
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// DiffOptions diff分页参数
+type DiffOptions struct {
+	Offset   int // 从第几个文件开始
+	Limit    int // 每页最多文件数
+	MaxBytes int // 每页patch总字节数上限，超出的文件被截断
+}
+
+// commitMetaFormat 提交人信息和完整说明，说明可能包含换行，因此单独读取
+const commitMetaFormat = "%cn%x00%ce%x00%cI%x00%B"
+
+// trailerPattern 形如 "Signed-off-by: Name <email>" 的trailer行
+var trailerPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// GetCommit 获取提交详情（不含diff）
+func (c *Calculator) GetCommit(ctx context.Context, localPath, sha string) (*models.CommitDetail, error) {
+	return c.reader.GetCommit(ctx, localPath, sha)
+}
+
+// ReadDiff 分页读取提交相对第一个父提交的unified diff
+func (c *Calculator) ReadDiff(ctx context.Context, localPath, sha string, parents []string, opts *DiffOptions) (*models.CommitDiff, error) {
+	return c.reader.ReadDiff(ctx, localPath, sha, parents, opts)
+}
+
+// GetCommit 获取提交详情，合并提交的numstat相对第一个父提交计算
+func (r *CmdLogReader) GetCommit(ctx context.Context, localPath, sha string) (*models.CommitDetail, error) {
+	cmd, cleanup, err := r.command(ctx, "-C", localPath,
+		"log", "-1", "-m", "--first-parent", "--numstat", "--format="+commitHeaderFormat, sha, "--")
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	commits, err := parseCommitLog(string(output))
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("commit %s not found", sha)
+	}
+
+	cmd, showCleanup, err := r.command(ctx, "-C", localPath, "show", "-s", "--format="+commitMetaFormat, sha)
+	if err != nil {
+		return nil, err
+	}
+	defer showCleanup()
+
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git show: %w", err)
+	}
+
+	fields := strings.SplitN(string(output), "\x00", 4)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("unexpected git show output for %s", sha)
+	}
+	commitDate, err := time.Parse(time.RFC3339, fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit date %q: %w", fields[2], err)
+	}
+
+	return newCommitDetail(commits[0], fields[0], fields[1], commitDate, fields[3]), nil
+}
+
+// ReadDiff 运行git diff并按文件分页，读够一页后即停止git进程
+func (r *CmdLogReader) ReadDiff(ctx context.Context, localPath, sha string, parents []string, opts *DiffOptions) (*models.CommitDiff, error) {
+	from := emptyTreeHash
+	if len(parents) > 0 {
+		from = parents[0]
+	}
+
+	logger.Logger.Debug().
+		Str("local_path", localPath).
+		Str("sha", sha).
+		Interface("options", opts).
+		Msg("reading commit diff")
+
+	diffCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd, cleanup, err := r.command(diffCtx, "-C", localPath, "-c", "core.quotePath=false",
+		"diff", "--no-color", "--no-ext-diff", from, sha, "--")
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start git diff: %w", err)
+	}
+
+	pager := newDiffPager(opts)
+	reader := bufio.NewReader(stdout)
+
+	var (
+		path     string
+		patch    strings.Builder
+		inFile   bool
+		complete = true
+	)
+	flush := func() bool {
+		if !inFile {
+			return true
+		}
+		return pager.add(path, patch.String())
+	}
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if strings.HasPrefix(line, "diff --git ") {
+			if !flush() {
+				complete = false
+				break
+			}
+			path = diffHeaderPath(line)
+			patch.Reset()
+			inFile = true
+		} else if inFile && strings.HasPrefix(line, "rename to ") {
+			path = strings.TrimSuffix(strings.TrimPrefix(line, "rename to "), "\n")
+		}
+
+		// 单个文件只保留到上限为止，其余内容丢弃
+		if inFile && !pager.skipping() && patch.Len() <= pager.maxBytes {
+			patch.WriteString(line)
+		}
+
+		if readErr == io.EOF {
+			complete = flush()
+			break
+		}
+		if readErr != nil {
+			cancel()
+			cmd.Wait()
+			return nil, fmt.Errorf("failed to read git diff output: %w", readErr)
+		}
+	}
+
+	if !complete {
+		// 已读够一页，不再等待剩余输出
+		cancel()
+		cmd.Wait()
+		return pager.result(), nil
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to run git diff: %w", err)
+	}
+
+	return pager.result(), nil
+}
+
+// diffHeaderPath 从 "diff --git a/<path> b/<path>" 中取出新路径
+func diffHeaderPath(line string) string {
+	rest := strings.TrimSuffix(strings.TrimPrefix(line, "diff --git "), "\n")
+
+	// 新旧路径相同时两半长度相等，可以正确处理路径中包含 " b/" 的情况
+	if n := len(rest); n%2 == 1 {
+		half := (n - 1) / 2
+		if rest[half] == ' ' && strings.TrimPrefix(rest[:half], "a/") == strings.TrimPrefix(rest[half+1:], "b/") {
+			return strings.TrimPrefix(rest[half+1:], "b/")
+		}
+	}
+
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return rest
+}
+
+// diffPager 按文件数和字节数切分diff
+type diffPager struct {
+	offset   int
+	limit    int
+	maxBytes int
+	index    int
+	used     int
+	diff     *models.CommitDiff
+}
+
+// newDiffPager 创建diff分页器
+func newDiffPager(opts *DiffOptions) *diffPager {
+	return &diffPager{
+		offset:   opts.Offset,
+		limit:    opts.Limit,
+		maxBytes: opts.MaxBytes,
+		diff: &models.CommitDiff{
+			Files:  make([]models.FileDiff, 0),
+			Offset: opts.Offset,
+		},
+	}
+}
+
+// skipping 当前文件是否在本页之前
+func (p *diffPager) skipping() bool {
+	return p.index < p.offset
+}
+
+// add 加入一个文件的patch，返回false表示本页已满
+func (p *diffPager) add(path, patch string) bool {
+	if p.skipping() {
+		p.index++
+		return true
+	}
+
+	if len(p.diff.Files) >= p.limit || p.used >= p.maxBytes {
+		p.diff.HasMore = true
+		p.diff.NextOffset = p.index
+		return false
+	}
+
+	file := models.FileDiff{Path: path, Patch: patch}
+	if remaining := p.maxBytes - p.used; len(patch) > remaining {
+		// 在行边界截断，并结束本页
+		cut := patch[:remaining]
+		if i := strings.LastIndexByte(cut, '\n'); i >= 0 {
+			cut = cut[:i+1]
+		}
+		file.Patch = cut
+		file.Truncated = true
+		p.used = p.maxBytes
+	} else {
+		p.used += len(patch)
+	}
+
+	p.diff.Files = append(p.diff.Files, file)
+	p.index++
+	return true
+}
+
+// result 返回本页结果
+func (p *diffPager) result() *models.CommitDiff {
+	return p.diff
+}
+
+// newCommitDetail 组装提交详情
+func newCommitDetail(commit models.Commit, committer, committerEmail string, commitDate time.Time, message string) *models.CommitDetail {
+	message = strings.TrimRight(message, "\n")
+	return &models.CommitDetail{
+		Commit:         commit,
+		Committer:      committer,
+		CommitterEmail: committerEmail,
+		CommitDate:     commitDate,
+		Message:        message,
+		Trailers:       parseTrailers(message),
+	}
+}
+
+// parseTrailers 解析提交说明最后一段中的trailer
+//
+// 与 git interpret-trailers 一致：最后一段的每一行都必须是 "Key: value"（或以空白开头的续行），
+// 只有标题行的提交说明没有trailer。
+func parseTrailers(message string) []models.Trailer {
+	trailers := make([]models.Trailer, 0)
+
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return trailers
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(paragraphs[len(paragraphs)-1]), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(trailers) > 0 {
+			last := &trailers[len(trailers)-1]
+			last.Value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		match := trailerPattern.FindStringSubmatch(line)
+		if match == nil {
+			return make([]models.Trailer, 0)
+		}
+		trailers = append(trailers, models.Trailer{Key: match[1], Value: strings.TrimSpace(match[2])})
+	}
+
+	return trailers
+}
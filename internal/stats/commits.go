@@ -0,0 +1,156 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// CommitQuery 提交浏览查询条件
+type CommitQuery struct {
+	Rev           string     // 已解析的commit hash
+	Author        string     // 作者名或邮箱，不区分大小写的子串匹配
+	Path          string     // 只看涉及该路径（文件或目录）的提交
+	Since         *time.Time // 提交时间下限
+	Until         *time.Time // 提交时间上限
+	Message       string     // 提交说明，不区分大小写的子串匹配
+	IncludeMerges bool       // 默认与统计一致，排除合并提交
+	Skip          int
+	Limit         int
+}
+
+// commitHeaderFormat 提交头格式，以NUL开头并以NUL分隔字段，避免与numstat行和说明中的字符混淆
+const commitHeaderFormat = "%x00%H%x00%P%x00%an%x00%ae%x00%aI%x00%s"
+
+// ListCommits 按条件列出提交，与统计读取同一份日志，排除合并提交的规则也一致
+func (c *Calculator) ListCommits(ctx context.Context, localPath string, query *CommitQuery) ([]models.Commit, error) {
+	return c.reader.ListCommits(ctx, localPath, query)
+}
+
+// ListCommits 运行git log列出提交
+func (r *CmdLogReader) ListCommits(ctx context.Context, localPath string, query *CommitQuery) ([]models.Commit, error) {
+	args := []string{
+		"-C", localPath,
+		"log",
+		"--numstat",
+		"--format=" + commitHeaderFormat,
+		"--regexp-ignore-case",
+		"--fixed-strings",
+	}
+	if !query.IncludeMerges {
+		args = append(args, "--no-merges")
+	}
+	if query.Author != "" {
+		args = append(args, "--author="+query.Author)
+	}
+	if query.Message != "" {
+		args = append(args, "--grep="+query.Message)
+	}
+	if query.Since != nil {
+		args = append(args, "--since="+query.Since.Format(time.RFC3339))
+	}
+	if query.Until != nil {
+		args = append(args, "--until="+query.Until.Format(time.RFC3339))
+	}
+	if query.Skip > 0 {
+		args = append(args, "--skip="+strconv.Itoa(query.Skip))
+	}
+	if query.Limit > 0 {
+		args = append(args, "-n", strconv.Itoa(query.Limit))
+	}
+
+	args = append(args, query.Rev, "--")
+	if query.Path != "" {
+		args = append(args, query.Path)
+	}
+
+	logger.Logger.Debug().
+		Str("local_path", localPath).
+		Interface("query", query).
+		Msg("listing commits")
+
+	cmd, cleanup, err := r.command(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	return parseCommitLog(string(output))
+}
+
+// parseCommitLog 解析 commitHeaderFormat 加 --numstat 的输出
+func parseCommitLog(output string) ([]models.Commit, error) {
+	commits := make([]models.Commit, 0)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "\x00") {
+			fields := strings.Split(line[1:], "\x00")
+			if len(fields) != 6 {
+				return nil, fmt.Errorf("unexpected commit header: %q", line)
+			}
+			date, err := time.Parse(time.RFC3339, fields[4])
+			if err != nil {
+				return nil, fmt.Errorf("invalid commit date %q: %w", fields[4], err)
+			}
+			commits = append(commits, models.Commit{
+				SHA:         fields[0],
+				Parents:     strings.Fields(fields[1]),
+				Author:      fields[2],
+				AuthorEmail: fields[3],
+				Date:        date,
+				Subject:     fields[5],
+				Files:       make([]models.CommitFile, 0),
+			})
+			continue
+		}
+
+		if len(commits) == 0 {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		addCommitFile(&commits[len(commits)-1], parts[0], parts[1], parts[2])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading git log output: %w", err)
+	}
+
+	return commits, nil
+}
+
+// addCommitFile 累加一行numstat，二进制文件显示为 -
+func addCommitFile(commit *models.Commit, additions, deletions, path string) {
+	file := models.CommitFile{Path: path}
+	if additions == "-" || deletions == "-" {
+		file.Binary = true
+	} else {
+		file.Additions, _ = strconv.Atoi(additions)
+		file.Deletions, _ = strconv.Atoi(deletions)
+	}
+
+	commit.Files = append(commit.Files, file)
+	commit.Additions += file.Additions
+	commit.Deletions += file.Deletions
+	commit.FilesChanged = len(commit.Files)
+}
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// Compare 对比两个周期的统计结果，贡献者按邮箱（忽略大小写）对应
+func Compare(base, target *models.Statistics) *models.StatsComparison {
+	comparison := &models.StatsComparison{
+		Commits:              newMetricDelta(base.Summary.TotalCommits, target.Summary.TotalCommits),
+		Contributors:         newMetricDelta(base.Summary.TotalContributors, target.Summary.TotalContributors),
+		ByContributor:        make([]models.ContributorDelta, 0),
+		NewContributors:      make([]string, 0),
+		DepartedContributors: make([]string, 0),
+	}
+
+	baseByEmail := make(map[string]models.ContributorStats, len(base.ByContributor))
+	for _, contrib := range base.ByContributor {
+		baseByEmail[strings.ToLower(contrib.Email)] = contrib
+	}
+
+	seen := make(map[string]bool, len(target.ByContributor))
+	for _, contrib := range target.ByContributor {
+		key := strings.ToLower(contrib.Email)
+		seen[key] = true
+
+		before, ok := baseByEmail[key]
+		status := models.ContributorStatusRetained
+		if !ok {
+			status = models.ContributorStatusNew
+			comparison.NewContributors = append(comparison.NewContributors, contrib.Email)
+		}
+		comparison.ByContributor = append(comparison.ByContributor, newContributorDelta(contrib, before, contrib, status))
+	}
+
+	for _, contrib := range base.ByContributor {
+		if seen[strings.ToLower(contrib.Email)] {
+			continue
+		}
+		comparison.DepartedContributors = append(comparison.DepartedContributors, contrib.Email)
+		comparison.ByContributor = append(comparison.ByContributor,
+			newContributorDelta(contrib, contrib, models.ContributorStats{}, models.ContributorStatusDeparted))
+	}
+
+	// 按提交数变化幅度排序
+	sort.SliceStable(comparison.ByContributor, func(i, j int) bool {
+		return abs(comparison.ByContributor[i].Commits.Delta) > abs(comparison.ByContributor[j].Commits.Delta)
+	})
+	sort.Strings(comparison.NewContributors)
+	sort.Strings(comparison.DepartedContributors)
+
+	return comparison
+}
+
+// newContributorDelta 计算单个贡献者的指标变化
+func newContributorDelta(identity, before, after models.ContributorStats, status string) models.ContributorDelta {
+	return models.ContributorDelta{
+		Author:        identity.Author,
+		Email:         identity.Email,
+		Status:        status,
+		Commits:       newMetricDelta(before.Commits, after.Commits),
+		Additions:     newMetricDelta(before.Additions, after.Additions),
+		Deletions:     newMetricDelta(before.Deletions, after.Deletions),
+		Modifications: newMetricDelta(before.Modifications, after.Modifications),
+		NetAdditions:  newMetricDelta(before.NetAdditions, after.NetAdditions),
+	}
+}
+
+// newMetricDelta 计算指标变化及百分比（保留两位小数）
+func newMetricDelta(base, target int) models.MetricDelta {
+	delta := models.MetricDelta{
+		Base:   base,
+		Target: target,
+		Delta:  target - base,
+	}
+
+	if base != 0 {
+		percent := math.Round(float64(target-base)/math.Abs(float64(base))*10000) / 100
+		delta.ChangePercent = &percent
+	}
+
+	return delta
+}
+
+// abs 返回整数绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,336 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/progress"
+)
+
+// GoGitLogReader 基于go-git的日志读取器，输出格式与CmdLogReader一致
+type GoGitLogReader struct{}
+
+// NewGoGitLogReader 创建基于go-git的日志读取器
+func NewGoGitLogReader() *GoGitLogReader {
+	return &GoGitLogReader{}
+}
+
+// ReadLog 遍历提交历史并生成numstat格式的日志
+func (r *GoGitLogReader) ReadLog(ctx context.Context, localPath, rev string, constraint *models.StatsConstraint) (string, error) {
+	if err := git.ValidateRef(rev); err != nil {
+		return "", err
+	}
+
+	repo, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	hash, err := git.ResolveGoGitRevision(repo, rev)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+
+	opts := &gogit.LogOptions{
+		From:  *hash,
+		Order: gogit.LogOrderCommitterTime,
+	}
+	limit := 0
+	if constraint != nil {
+		if constraint.Type == models.ConstraintTypeDateRange {
+			if constraint.From != "" {
+				since, err := git.ParseDate(constraint.From, false)
+				if err != nil {
+					return "", err
+				}
+				opts.Since = &since
+			}
+			if constraint.To != "" {
+				until, err := git.ParseDate(constraint.To, true)
+				if err != nil {
+					return "", err
+				}
+				opts.Until = &until
+			}
+		} else if constraint.Type == models.ConstraintTypeCommitLimit {
+			limit = constraint.Limit
+		}
+	}
+
+	logger.Logger.Debug().
+		Str("local_path", localPath).
+		Str("rev", rev).
+		Interface("constraint", constraint).
+		Msg("reading log with go-git")
+
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+	defer iter.Close()
+
+	total := 0
+	if progress.Enabled(ctx) {
+		total = countGoGitCommits(repo, opts, limit)
+	}
+
+	var sb strings.Builder
+	count := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// 与 --no-merges 一致，跳过合并提交
+		if c.NumParents() > 1 {
+			return nil
+		}
+
+		fileStats, err := c.StatsContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get stats of commit %s: %w", c.Hash, err)
+		}
+
+		fmt.Fprintf(&sb, "COMMIT:%s|AUTHOR:%s|EMAIL:%s|DATE:%s\n",
+			c.Hash, c.Author.Name, c.Author.Email, c.Author.When.Format("2006-01-02 15:04:05 -0700"))
+		for _, fs := range fileStats {
+			fmt.Fprintf(&sb, "%d\t%d\t%s\n", fs.Addition, fs.Deletion, fs.Name)
+		}
+		sb.WriteString("\n")
+
+		count++
+		reportCommits(ctx, count, total)
+		if limit > 0 && count >= limit {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// countGoGitCommits 统计约束范围内的非合并提交数，用于计算进度，失败时返回0
+//
+// 只遍历提交对象不计算差异，相比读取numstat开销很小。
+func countGoGitCommits(repo *gogit.Repository, opts *gogit.LogOptions, limit int) int {
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return 0
+	}
+	defer iter.Close()
+
+	total := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if c.NumParents() > 1 {
+			return nil
+		}
+		total++
+		if limit > 0 && total >= limit {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return 0
+	}
+	return total
+}
+
+// ListCommits 遍历提交历史并按条件过滤
+func (r *GoGitLogReader) ListCommits(ctx context.Context, localPath string, query *CommitQuery) ([]models.Commit, error) {
+	repo, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	hash, err := git.ResolveGoGitRevision(repo, query.Rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", query.Rev, err)
+	}
+
+	opts := &gogit.LogOptions{
+		From:  *hash,
+		Order: gogit.LogOrderCommitterTime,
+	}
+	if query.Path != "" {
+		opts.PathFilter = func(name string) bool {
+			return pathMatches(name, query.Path)
+		}
+	}
+
+	iter, err := repo.Log(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+	defer iter.Close()
+
+	author := strings.ToLower(query.Author)
+	message := strings.ToLower(query.Message)
+
+	commits := make([]models.Commit, 0)
+	skipped := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// 与git log的过滤条件保持一致：作者/提交说明为不区分大小写的子串，时间范围按提交时间
+		if !query.IncludeMerges && c.NumParents() > 1 {
+			return nil
+		}
+		if author != "" && !strings.Contains(strings.ToLower(c.Author.Name+" <"+c.Author.Email+">"), author) {
+			return nil
+		}
+		if message != "" && !strings.Contains(strings.ToLower(c.Message), message) {
+			return nil
+		}
+		if query.Since != nil && c.Committer.When.Before(*query.Since) {
+			return nil
+		}
+		if query.Until != nil && c.Committer.When.After(*query.Until) {
+			return nil
+		}
+		if skipped < query.Skip {
+			skipped++
+			return nil
+		}
+
+		commit := models.Commit{
+			SHA:         c.Hash.String(),
+			Parents:     make([]string, 0, c.NumParents()),
+			Author:      c.Author.Name,
+			AuthorEmail: c.Author.Email,
+			Date:        c.Author.When,
+			Subject:     strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0],
+			Files:       make([]models.CommitFile, 0),
+		}
+		for _, p := range c.ParentHashes {
+			commit.Parents = append(commit.Parents, p.String())
+		}
+
+		fileStats, err := c.StatsContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get stats of commit %s: %w", c.Hash, err)
+		}
+		for _, fs := range fileStats {
+			if query.Path != "" && !pathMatches(fs.Name, query.Path) {
+				continue
+			}
+			addCommitFile(&commit, strconv.Itoa(fs.Addition), strconv.Itoa(fs.Deletion), fs.Name)
+		}
+
+		commits = append(commits, commit)
+		if query.Limit > 0 && len(commits) >= query.Limit {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	return commits, nil
+}
+
+// pathMatches 判断文件是否为该路径本身或位于该目录下
+func pathMatches(name, path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	return name == path || strings.HasPrefix(name, path+"/")
+}
+
+// GetCommit 获取提交详情，合并提交的numstat相对第一个父提交计算
+func (r *GoGitLogReader) GetCommit(ctx context.Context, localPath, sha string) (*models.CommitDetail, error) {
+	commits, err := r.ListCommits(ctx, localPath, &CommitQuery{Rev: sha, IncludeMerges: true, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("commit %s not found", sha)
+	}
+
+	repo, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	c, err := repo.CommitObject(plumbing.NewHash(commits[0].SHA))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	return newCommitDetail(commits[0], c.Committer.Name, c.Committer.Email, c.Committer.When, c.Message), nil
+}
+
+// ReadDiff 比较提交与第一个父提交的树并按文件分页，只为本页文件生成patch
+func (r *GoGitLogReader) ReadDiff(ctx context.Context, localPath, sha string, parents []string, opts *DiffOptions) (*models.CommitDiff, error) {
+	repo, err := gogit.PlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	c, err := repo.CommitObject(plumbing.NewHash(sha))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", sha, err)
+	}
+
+	// 根提交与空树比较
+	var parentTree *object.Tree
+	if len(parents) > 0 {
+		parent, err := repo.CommitObject(plumbing.NewHash(parents[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent commit %s: %w", parents[0], err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to get tree of commit %s: %w", parents[0], err)
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff commit %s: %w", sha, err)
+	}
+
+	pager := newDiffPager(opts)
+	for _, change := range changes {
+		path := change.To.Name
+		if path == "" {
+			path = change.From.Name
+		}
+
+		if pager.skipping() {
+			pager.add(path, "")
+			continue
+		}
+
+		patch, err := change.PatchContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get patch of %s: %w", path, err)
+		}
+		var sb strings.Builder
+		if err := diff.NewUnifiedEncoder(&sb, diff.DefaultContextLines).Encode(patch); err != nil {
+			return nil, fmt.Errorf("failed to encode patch of %s: %w", path, err)
+		}
+
+		if !pager.add(path, sb.String()) {
+			break
+		}
+	}
+
+	return pager.result(), nil
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// commitDateLayout git log %ai 输出的日期格式
+const commitDateLayout = "2006-01-02 15:04:05 -0700"
+
+// Merge 合并多份统计结果，按邮箱统一贡献者
+//
+// 邮箱（忽略大小写）相同的记录视为同一人，合并后的作者名和邮箱取提交数最多的
+// 那条记录。作者名相同但邮箱不同的记录不会合并，避免同名的不同开发者或共用的
+// 机器人名称被错误归并。
+func Merge(parts []*models.Statistics) *models.Statistics {
+	totalCommits := 0
+	groups := make(map[string][]models.ContributorStats)
+	keys := make([]string, 0)
+	for _, part := range parts {
+		if part == nil {
+			continue
+		}
+		totalCommits += part.Summary.TotalCommits
+		for _, entry := range part.ByContributor {
+			key := normalizeIdentity(entry.Email)
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], entry)
+		}
+	}
+
+	merged := &models.Statistics{
+		ByContributor: make([]models.ContributorStats, 0, len(keys)),
+	}
+	for _, key := range keys {
+		merged.ByContributor = append(merged.ByContributor, mergeContributor(groups[key]))
+	}
+
+	sort.Slice(merged.ByContributor, func(i, j int) bool {
+		return merged.ByContributor[i].Commits > merged.ByContributor[j].Commits
+	})
+
+	merged.Summary.TotalCommits = totalCommits
+	merged.Summary.TotalContributors = len(merged.ByContributor)
+
+	return merged
+}
+
+// mergeContributor 合并同一身份的多条贡献者记录
+func mergeContributor(group []models.ContributorStats) models.ContributorStats {
+	result := models.ContributorStats{}
+	primaryCommits := -1
+
+	for _, entry := range group {
+		if entry.Commits > primaryCommits {
+			primaryCommits = entry.Commits
+			result.Author = entry.Author
+			result.Email = entry.Email
+		}
+
+		result.Commits += entry.Commits
+		result.Additions += entry.Additions
+		result.Deletions += entry.Deletions
+
+		if result.FirstCommitDate == "" || commitDateBefore(entry.FirstCommitDate, result.FirstCommitDate) {
+			result.FirstCommitDate = entry.FirstCommitDate
+		}
+		if result.LastCommitDate == "" || commitDateBefore(result.LastCommitDate, entry.LastCommitDate) {
+			result.LastCommitDate = entry.LastCommitDate
+		}
+	}
+
+	result.Modifications = min(result.Additions, result.Deletions)
+	result.NetAdditions = result.Additions - result.Deletions
+
+	return result
+}
+
+// commitDateBefore 比较git log %ai格式的日期，解析失败时按字符串比较
+func commitDateBefore(a, b string) bool {
+	ta, errA := time.Parse(commitDateLayout, a)
+	tb, errB := time.Parse(commitDateLayout, b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return ta.Before(tb)
+}
+
+// normalizeIdentity 规范化邮箱用于身份比较
+func normalizeIdentity(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,181 @@
+package teams
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// Mapper 贡献者邮箱到团队的映射
+type Mapper struct {
+	teams   []compiledTeam
+	version string
+}
+
+// compiledTeam 预处理后的团队定义
+type compiledTeam struct {
+	name     string
+	emails   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewMapper 根据团队定义创建映射
+func NewMapper(teams []*models.Team) (*Mapper, error) {
+	sorted := make([]*models.Team, len(teams))
+	copy(sorted, teams)
+	// 按名称排序，保证匹配顺序和版本号与定义顺序无关
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	m := &Mapper{
+		teams: make([]compiledTeam, 0, len(sorted)),
+	}
+
+	hasher := sha256.New()
+	seen := make(map[string]bool, len(sorted))
+	for _, team := range sorted {
+		if err := Validate(team); err != nil {
+			return nil, err
+		}
+		// 同名团队在汇总时会被合并，必须在加载时拒绝
+		key := strings.ToLower(strings.TrimSpace(team.Name))
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate team name %q", team.Name)
+		}
+		seen[key] = true
+
+		ct := compiledTeam{
+			name:   team.Name,
+			emails: make(map[string]bool),
+		}
+
+		emails := normalizeEmails(team.Emails)
+		for _, email := range emails {
+			ct.emails[email] = true
+		}
+
+		patterns := make([]string, len(team.Patterns))
+		copy(patterns, team.Patterns)
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			ct.patterns = append(ct.patterns, regexp.MustCompile("(?i)"+pattern))
+		}
+
+		fmt.Fprintf(hasher, "team:%s|emails:%s|patterns:%s\n",
+			team.Name, strings.Join(emails, ","), strings.Join(patterns, ","))
+
+		m.teams = append(m.teams, ct)
+	}
+
+	m.version = hex.EncodeToString(hasher.Sum(nil))[:16]
+
+	return m, nil
+}
+
+// Version 返回映射版本（由团队定义内容决定）
+func (m *Mapper) Version() string {
+	return m.version
+}
+
+// Resolve 返回邮箱所属团队，精确邮箱优先于正则匹配
+func (m *Mapper) Resolve(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	for _, team := range m.teams {
+		if team.emails[email] {
+			return team.name
+		}
+	}
+
+	for _, team := range m.teams {
+		for _, pattern := range team.patterns {
+			if pattern.MatchString(email) {
+				return team.name
+			}
+		}
+	}
+
+	return models.TeamUnassigned
+}
+
+// Aggregate 将贡献者统计汇总为团队统计
+func (m *Mapper) Aggregate(contributors []models.ContributorStats) []models.TeamStats {
+	byName := make(map[string]*models.TeamStats, len(m.teams)+1)
+	result := make([]*models.TeamStats, 0, len(m.teams)+1)
+
+	// 所有已定义团队都会出现在结果中，unassigned 放在最后
+	for _, team := range m.teams {
+		ts := &models.TeamStats{Team: team.name, Members: make([]string, 0)}
+		byName[team.name] = ts
+		result = append(result, ts)
+	}
+	unassigned := &models.TeamStats{Team: models.TeamUnassigned, Members: make([]string, 0)}
+	byName[models.TeamUnassigned] = unassigned
+	result = append(result, unassigned)
+
+	for _, contrib := range contributors {
+		ts := byName[m.Resolve(contrib.Email)]
+		ts.Members = append(ts.Members, contrib.Email)
+		ts.Contributors++
+		ts.Commits += contrib.Commits
+		ts.Additions += contrib.Additions
+		ts.Deletions += contrib.Deletions
+		ts.Modifications += contrib.Modifications
+		ts.NetAdditions += contrib.NetAdditions
+	}
+
+	teamStats := make([]models.TeamStats, 0, len(result))
+	for _, ts := range result {
+		sort.Strings(ts.Members)
+		teamStats = append(teamStats, *ts)
+	}
+
+	return teamStats
+}
+
+// Validate 校验团队定义
+func Validate(team *models.Team) error {
+	name := strings.TrimSpace(team.Name)
+	if name == "" {
+		return errors.New("team name is required")
+	}
+	if strings.EqualFold(name, models.TeamUnassigned) {
+		return fmt.Errorf("team name %q is reserved", models.TeamUnassigned)
+	}
+	if len(team.Emails) == 0 && len(team.Patterns) == 0 {
+		return fmt.Errorf("team %s requires at least one email or pattern", name)
+	}
+
+	for _, pattern := range team.Patterns {
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return fmt.Errorf("team %s has invalid pattern %q: %w", name, pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeEmails 邮箱转小写、去重并排序
+func normalizeEmails(emails []string) []string {
+	seen := make(map[string]bool, len(emails))
+	normalized := make([]string, 0, len(emails))
+
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		normalized = append(normalized, email)
+	}
+
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,65 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+// Registry 团队定义注册表，合并配置文件与API维护的团队
+type Registry struct {
+	store       storage.Store
+	configTeams []config.TeamConfig
+}
+
+// NewRegistry 创建团队注册表
+func NewRegistry(store storage.Store, configTeams []config.TeamConfig) *Registry {
+	return &Registry{
+		store:       store,
+		configTeams: configTeams,
+	}
+}
+
+// List 列出所有团队，配置文件中的团队在前
+func (r *Registry) List(ctx context.Context) ([]*models.Team, error) {
+	teams := r.ConfigTeams()
+
+	stored, err := r.store.Teams().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	for _, team := range stored {
+		team.Source = models.TeamSourceAPI
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+// ConfigTeams 返回配置文件中定义的团队
+func (r *Registry) ConfigTeams() []*models.Team {
+	teams := make([]*models.Team, 0, len(r.configTeams))
+	for _, tc := range r.configTeams {
+		teams = append(teams, &models.Team{
+			Name:     tc.Name,
+			Emails:   tc.Emails,
+			Patterns: tc.Patterns,
+			Source:   models.TeamSourceConfig,
+		})
+	}
+	return teams
+}
+
+// Mapper 根据当前团队定义构建映射
+func (r *Registry) Mapper(ctx context.Context) (*Mapper, error) {
+	teams, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMapper(teams)
+}
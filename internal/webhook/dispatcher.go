@@ -0,0 +1,445 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+)
+
+// 投递请求头
+const (
+	SignatureHeader = "X-GitCodeStatic-Signature-256" // "sha256=" 加请求体的HMAC-SHA256
+	EventHeader     = "X-GitCodeStatic-Event"
+	DeliveryHeader  = "X-GitCodeStatic-Delivery"
+)
+
+const (
+	// taskBufferSize 等待转换为事件的任务缓冲，满时丢弃并记录日志，避免阻塞worker
+	taskBufferSize = 256
+	// publishTimeout 生成一个任务的事件和投递记录的超时时间
+	publishTimeout = 30 * time.Second
+	// maxResponseBody 读取响应体的上限，响应内容不保存
+	maxResponseBody = 64 * 1024
+)
+
+// TaskEventData task.completed / task.failed 的事件数据
+type TaskEventData struct {
+	Task *models.Task `json:"task"`
+}
+
+// RepoEventData repo.ready / repo.failed 的事件数据
+type RepoEventData struct {
+	Repository *models.Repository `json:"repository"`
+	TaskID     int64              `json:"task_id"`
+}
+
+// StatsEventData stats.ready 的事件数据，可用 cache_key 查询统计结果
+type StatsEventData struct {
+	TaskID     int64                   `json:"task_id"`
+	RepoID     int64                   `json:"repo_id"`
+	Branch     string                  `json:"branch"`
+	Constraint *models.StatsConstraint `json:"constraint,omitempty"`
+	CacheKey   string                  `json:"cache_key"`
+}
+
+// taskEvent 由任务生成的一个事件
+type taskEvent struct {
+	name string
+	data interface{}
+}
+
+// Dispatcher 将任务状态变化转换为事件，投递给订阅方并按指数退避重试
+//
+// 投递记录先写入存储再发送，停止时未完成的投递保持pending，下次启动后继续。
+type Dispatcher struct {
+	store  storage.Store
+	cfg    config.OutboundWebhookConfig
+	client *http.Client
+	tasks  chan *models.Task
+	sem    chan struct{}
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	timers map[int64]*time.Timer
+}
+
+// NewDispatcher 创建事件投递器
+func NewDispatcher(store storage.Store, cfg config.OutboundWebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		tasks:  make(chan *models.Task, taskBufferSize),
+		sem:    make(chan struct{}, cfg.Concurrency),
+		stopCh: make(chan struct{}),
+		timers: make(map[int64]*time.Timer),
+	}
+}
+
+// Start 启动事件处理，并恢复上次未完成的投递
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go d.run()
+
+	deliveries, err := d.store.Webhooks().ListDeliveries(context.Background(), 0, models.DeliveryStatusPending, 0)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("failed to list pending webhook deliveries")
+		return
+	}
+	for _, delivery := range deliveries {
+		var delay time.Duration
+		if delivery.NextAttemptAt != nil {
+			delay = time.Until(*delivery.NextAttemptAt)
+		}
+		d.schedule(delivery.ID, delay)
+	}
+
+	logger.Logger.Info().Int("pending", len(deliveries)).Msg("webhook dispatcher started")
+}
+
+// Stop 停止投递，等待进行中的请求结束
+func (d *Dispatcher) Stop() {
+	close(d.stopCh)
+
+	d.mu.Lock()
+	for id, timer := range d.timers {
+		if timer.Stop() {
+			d.wg.Done()
+		}
+		delete(d.timers, id)
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	logger.Logger.Info().Msg("webhook dispatcher stopped")
+}
+
+// TaskUpdated 实现 worker.TaskListener，只处理已结束的任务
+func (d *Dispatcher) TaskUpdated(task *models.Task) {
+	if task.Status != models.TaskStatusCompleted && task.Status != models.TaskStatusFailed {
+		return
+	}
+
+	select {
+	case d.tasks <- task:
+	default:
+		logger.Logger.Warn().Int64("task_id", task.ID).Msg("webhook event buffer full, dropping task event")
+	}
+}
+
+// run 依次将任务转换为事件
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case task := <-d.tasks:
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			for _, event := range d.taskEvents(ctx, task) {
+				if err := d.Publish(ctx, event.name, event.data); err != nil {
+					logger.Logger.Error().Err(err).Str("event", event.name).Int64("task_id", task.ID).Msg("failed to publish webhook event")
+				}
+			}
+			cancel()
+		}
+	}
+}
+
+// taskEvents 根据任务类型和结果生成事件，task.* 事件总在最前
+func (d *Dispatcher) taskEvents(ctx context.Context, task *models.Task) []taskEvent {
+	completed := task.Status == models.TaskStatusCompleted
+
+	events := make([]taskEvent, 0, 2)
+	if completed {
+		events = append(events, taskEvent{models.EventTaskCompleted, &TaskEventData{Task: task}})
+	} else {
+		events = append(events, taskEvent{models.EventTaskFailed, &TaskEventData{Task: task}})
+	}
+
+	switch task.TaskType {
+	case models.TaskTypeClone, models.TaskTypeReset:
+		repo, err := d.store.Repos().GetByID(ctx, task.RepoID)
+		if err != nil || repo == nil {
+			logger.Logger.Warn().Err(err).Int64("repo_id", task.RepoID).Msg("failed to get repository for webhook event")
+			break
+		}
+		data := &RepoEventData{Repository: repo, TaskID: task.ID}
+		if completed && repo.Status == models.RepoStatusReady {
+			events = append(events, taskEvent{models.EventRepoReady, data})
+		} else if !completed && repo.Status == models.RepoStatusFailed {
+			events = append(events, taskEvent{models.EventRepoFailed, data})
+		}
+
+	case models.TaskTypeStats:
+		if !completed || task.Result == nil {
+			break
+		}
+		var params models.TaskParameters
+		var result models.TaskResult
+		json.Unmarshal([]byte(task.Parameters), &params)
+		if err := json.Unmarshal([]byte(*task.Result), &result); err != nil || result.CacheKey == "" {
+			break
+		}
+		events = append(events, taskEvent{models.EventStatsReady, &StatsEventData{
+			TaskID:     task.ID,
+			RepoID:     task.RepoID,
+			Branch:     params.Branch,
+			Constraint: params.Constraint,
+			CacheKey:   result.CacheKey,
+		}})
+	}
+
+	return events
+}
+
+// Publish 为订阅了该事件的所有订阅创建投递记录并投递
+func (d *Dispatcher) Publish(ctx context.Context, event string, data interface{}) error {
+	subs, err := d.store.Webhooks().ListSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	var (
+		eventID string
+		payload []byte
+	)
+	for _, sub := range subs {
+		if !sub.Enabled || !subscribes(sub, event) {
+			continue
+		}
+
+		// 所有订阅共用同一事件ID和请求体
+		if payload == nil {
+			if eventID, payload, err = newEventPayload(event, data); err != nil {
+				return err
+			}
+		}
+
+		delivery, err := d.createDelivery(ctx, sub, eventID, event, payload)
+		if err != nil {
+			return err
+		}
+		d.schedule(delivery.ID, 0)
+	}
+
+	return nil
+}
+
+// Ping 向订阅同步投递一次 ping 事件，失败时与普通事件一样重试
+func (d *Dispatcher) Ping(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	eventID, payload, err := newEventPayload(models.EventPing, map[string]interface{}{"subscription_id": sub.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := d.createDelivery(ctx, sub, eventID, models.EventPing, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.attempt(ctx, delivery.ID), nil
+}
+
+// createDelivery 保存待投递记录
+func (d *Dispatcher) createDelivery(ctx context.Context, sub *models.WebhookSubscription, eventID, event string, payload []byte) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        eventID,
+		Event:          event,
+		Payload:        string(payload),
+		Status:         models.DeliveryStatusPending,
+		NextAttemptAt:  &now,
+	}
+	if err := d.store.Webhooks().CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// schedule 在delay后投递，已停止时不再安排
+func (d *Dispatcher) schedule(deliveryID int64, delay time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	select {
+	case <-d.stopCh:
+		return
+	default:
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	d.wg.Add(1)
+	d.timers[deliveryID] = time.AfterFunc(delay, func() {
+		defer d.wg.Done()
+
+		d.mu.Lock()
+		delete(d.timers, deliveryID)
+		d.mu.Unlock()
+
+		select {
+		case d.sem <- struct{}{}:
+			defer func() { <-d.sem }()
+		case <-d.stopCh:
+			return
+		}
+
+		d.attempt(context.Background(), deliveryID)
+	})
+}
+
+// attempt 投递一次并记录结果，失败且未达到次数上限时安排重试
+func (d *Dispatcher) attempt(ctx context.Context, deliveryID int64) *models.WebhookDelivery {
+	delivery, err := d.store.Webhooks().GetDelivery(ctx, deliveryID)
+	if err != nil || delivery == nil {
+		logger.Logger.Error().Err(err).Int64("delivery_id", deliveryID).Msg("failed to get webhook delivery")
+		return nil
+	}
+	if delivery.Status != models.DeliveryStatusPending {
+		return delivery
+	}
+
+	sub, err := d.store.Webhooks().GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		logger.Logger.Error().Err(err).Int64("subscription_id", delivery.SubscriptionID).Msg("failed to get webhook subscription")
+		return delivery
+	}
+
+	now := time.Now()
+	delivery.NextAttemptAt = nil
+	switch {
+	case sub == nil:
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = "subscription deleted"
+	case !sub.Enabled && delivery.Event != models.EventPing:
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = "subscription disabled"
+	default:
+		delivery.Attempts++
+		delivery.ResponseCode, err = d.send(ctx, sub, delivery)
+		if err == nil {
+			delivery.Status = models.DeliveryStatusSuccess
+			delivery.Error = ""
+			delivery.DeliveredAt = &now
+		} else {
+			delivery.Error = err.Error()
+			if delivery.Attempts >= d.cfg.MaxAttempts {
+				delivery.Status = models.DeliveryStatusFailed
+			} else {
+				next := now.Add(d.backoff(delivery.Attempts))
+				delivery.NextAttemptAt = &next
+			}
+		}
+	}
+
+	if err := d.store.Webhooks().UpdateDelivery(ctx, delivery); err != nil {
+		logger.Logger.Error().Err(err).Int64("delivery_id", delivery.ID).Msg("failed to update webhook delivery")
+		return delivery
+	}
+
+	event := logger.Logger.Info()
+	if delivery.Status != models.DeliveryStatusSuccess {
+		event = logger.Logger.Warn().Str("error", delivery.Error)
+	}
+	event.Int64("delivery_id", delivery.ID).
+		Int64("subscription_id", delivery.SubscriptionID).
+		Str("event", delivery.Event).
+		Str("status", delivery.Status).
+		Int("attempts", delivery.Attempts).
+		Int("response_code", delivery.ResponseCode).
+		Msg("webhook delivery attempted")
+
+	if delivery.NextAttemptAt != nil {
+		d.schedule(delivery.ID, time.Until(*delivery.NextAttemptAt))
+	}
+
+	return delivery
+}
+
+// send 发送签名的请求，非2xx响应视为失败
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GitCodeStatic-Webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff 第n次失败后的等待时间：initial * 2^(n-1)，不超过上限
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.InitialBackoff
+	for i := 1; i < attempts && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.cfg.MaxBackoff {
+		wait = d.cfg.MaxBackoff
+	}
+	return wait
+}
+
+// subscribes 订阅是否包含事件
+func subscribes(sub *models.WebhookSubscription, event string) bool {
+	for _, e := range sub.Events {
+		if e == models.EventAll || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// newEventPayload 生成带唯一ID的事件请求体
+func newEventPayload(event string, data interface{}) (string, []byte, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate event id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	payload, err := json.Marshal(&models.WebhookEvent{
+		ID:         id,
+		Event:      event,
+		OccurredAt: time.Now(),
+		Data:       data,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+	return id, payload, nil
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// gitea Gitea/Forgejo webhook，X-Gitea-Signature 为请求体的HMAC-SHA256（十六进制，无前缀）
+type gitea struct {
+	secret string
+}
+
+// giteaPush Gitea push事件中用到的字段
+type giteaPush struct {
+	Ref        string            `json:"ref"`
+	Before     string            `json:"before"`
+	After      string            `json:"after"`
+	Commits    []json.RawMessage `json:"commits"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// Verify 校验签名
+func (p *gitea) Verify(header http.Header, body []byte) error {
+	return verifyHMAC(p.secret, body, header.Get("X-Gitea-Signature"))
+}
+
+// ParsePush 解析push事件
+func (p *gitea) ParsePush(header http.Header, body []byte) (*PushEvent, error) {
+	if event := header.Get("X-Gitea-Event"); event != "push" {
+		return nil, fmt.Errorf("%w: %s", ErrIgnoredEvent, event)
+	}
+
+	var payload giteaPush
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid gitea push payload: %w", err)
+	}
+
+	return newPushEvent(ProviderGitea, payload.Ref, payload.Before, payload.After, len(payload.Commits),
+		payload.Repository.CloneURL, payload.Repository.SSHURL, payload.Repository.HTMLURL), nil
+}
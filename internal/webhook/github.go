@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// gitHub GitHub webhook，X-Hub-Signature-256 为 "sha256=" 加请求体的HMAC-SHA256
+type gitHub struct {
+	secret string
+}
+
+// gitHubPush GitHub push事件中用到的字段
+type gitHubPush struct {
+	Ref        string            `json:"ref"`
+	Before     string            `json:"before"`
+	After      string            `json:"after"`
+	Deleted    bool              `json:"deleted"`
+	Commits    []json.RawMessage `json:"commits"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+		SSHURL   string `json:"ssh_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// Verify 校验签名
+func (p *gitHub) Verify(header http.Header, body []byte) error {
+	signature := header.Get("X-Hub-Signature-256")
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+	return verifyHMAC(p.secret, body, strings.TrimPrefix(signature, "sha256="))
+}
+
+// ParsePush 解析push事件
+func (p *gitHub) ParsePush(header http.Header, body []byte) (*PushEvent, error) {
+	if event := header.Get("X-GitHub-Event"); event != "push" {
+		return nil, fmt.Errorf("%w: %s", ErrIgnoredEvent, event)
+	}
+
+	var payload gitHubPush
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid github push payload: %w", err)
+	}
+
+	event := newPushEvent(ProviderGitHub, payload.Ref, payload.Before, payload.After, len(payload.Commits),
+		payload.Repository.CloneURL, payload.Repository.SSHURL, payload.Repository.HTMLURL)
+	event.Deleted = event.Deleted || payload.Deleted
+
+	return event, nil
+}
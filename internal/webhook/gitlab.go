@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// gitLab GitLab webhook，X-Gitlab-Token 为配置的明文令牌
+type gitLab struct {
+	token string
+}
+
+// gitLabPush GitLab Push Hook / Tag Push Hook 中用到的字段
+type gitLabPush struct {
+	Ref               string `json:"ref"`
+	Before            string `json:"before"`
+	After             string `json:"after"`
+	TotalCommitsCount int    `json:"total_commits_count"`
+	Project           struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+}
+
+// Verify 校验令牌
+func (p *gitLab) Verify(header http.Header, body []byte) error {
+	return verifyToken(p.token, header.Get("X-Gitlab-Token"))
+}
+
+// ParsePush 解析push事件
+func (p *gitLab) ParsePush(header http.Header, body []byte) (*PushEvent, error) {
+	if event := header.Get("X-Gitlab-Event"); event != "Push Hook" && event != "Tag Push Hook" {
+		return nil, fmt.Errorf("%w: %s", ErrIgnoredEvent, event)
+	}
+
+	var payload gitLabPush
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid gitlab push payload: %w", err)
+	}
+
+	return newPushEvent(ProviderGitLab, payload.Ref, payload.Before, payload.After, payload.TotalCommitsCount,
+		payload.Project.GitHTTPURL, payload.Project.GitSSHURL, payload.Project.WebURL), nil
+}
@@ -0,0 +1,136 @@
+// Package webhook 接收代码托管平台推送的webhook，并向订阅方投递任务和仓库事件
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnknownProvider 不支持的平台
+	ErrUnknownProvider = errors.New("unknown webhook provider")
+	// ErrProviderDisabled 平台未配置密钥，拒绝接收
+	ErrProviderDisabled = errors.New("webhook provider is not configured")
+	// ErrInvalidSignature 签名或令牌校验失败
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrIgnoredEvent 非push事件，无需处理
+	ErrIgnoredEvent = errors.New("event ignored")
+)
+
+// Provider Constants
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+// zeroSHA 创建或删除分支时 before/after 使用的全零提交
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// PushEvent 解析后的push事件
+type PushEvent struct {
+	Provider string   `json:"provider"`
+	Ref      string   `json:"ref"`              // 如 refs/heads/main
+	Branch   string   `json:"branch,omitempty"` // 推送分支时的分支名
+	Tag      string   `json:"tag,omitempty"`    // 推送标签时的标签名
+	Before   string   `json:"before"`
+	After    string   `json:"after"`
+	Deleted  bool     `json:"deleted"` // 删除分支或标签
+	RepoURLs []string `json:"repo_urls"`
+	Commits  int      `json:"commits"`
+}
+
+// Provider 代码托管平台
+type Provider interface {
+	// Verify 校验请求签名或令牌
+	Verify(header http.Header, body []byte) error
+	// ParsePush 解析push事件，非push事件返回 ErrIgnoredEvent
+	ParsePush(header http.Header, body []byte) (*PushEvent, error)
+}
+
+// NewProvider 创建平台校验/解析器，secret为空时返回 ErrProviderDisabled
+func NewProvider(name, secret string) (Provider, error) {
+	var p Provider
+	switch name {
+	case ProviderGitHub:
+		p = &gitHub{secret: secret}
+	case ProviderGitLab:
+		p = &gitLab{token: secret}
+	case ProviderGitea:
+		p = &gitea{secret: secret}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	if secret == "" {
+		return nil, fmt.Errorf("%w: %s", ErrProviderDisabled, name)
+	}
+	return p, nil
+}
+
+// verifyHMAC 校验十六进制的HMAC-SHA256签名
+func verifyHMAC(secret string, body []byte, signature string) error {
+	got, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(got) == 0 {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(got, hmacSHA256(secret, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign 计算投递请求的签名，格式与GitHub的 X-Hub-Signature-256 相同
+func Sign(secret string, body []byte) string {
+	return "sha256=" + hex.EncodeToString(hmacSHA256(secret, body))
+}
+
+// hmacSHA256 计算请求体的HMAC-SHA256
+func hmacSHA256(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// verifyToken 常量时间比较令牌
+func verifyToken(expected, got string) error {
+	if got == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(got)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// newPushEvent 根据ref和前后提交构造事件
+func newPushEvent(provider, ref, before, after string, commits int, urls ...string) *PushEvent {
+	event := &PushEvent{
+		Provider: provider,
+		Ref:      ref,
+		Before:   before,
+		After:    after,
+		Deleted:  after == zeroSHA,
+		RepoURLs: make([]string, 0, len(urls)),
+		Commits:  commits,
+	}
+
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		event.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	case strings.HasPrefix(ref, "refs/tags/"):
+		event.Tag = strings.TrimPrefix(ref, "refs/tags/")
+	}
+
+	for _, u := range urls {
+		if u != "" {
+			event.RepoURLs = append(event.RepoURLs, u)
+		}
+	}
+
+	return event
+}
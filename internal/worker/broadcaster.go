@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"sync"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲，消费过慢时丢弃新事件
+const subscriberBuffer = 64
+
+// TaskEvent 推送给订阅者的任务事件，Task为事件发生时的快照
+type TaskEvent struct {
+	Type string       `json:"type"`
+	Task *models.Task `json:"task"`
+}
+
+// Task Event Type constants
+const (
+	TaskEventStatus   = "status"   // 状态变化，包括新建任务
+	TaskEventProgress = "progress" // 状态不变的更新，如运行中的进度
+)
+
+// Broadcaster 将任务更新分发给订阅者（如SSE连接），实现 TaskListener
+type Broadcaster struct {
+	mu     sync.Mutex
+	subs   map[chan TaskEvent]int64 // 订阅者及其关注的任务ID，0表示全部
+	status map[int64]string         // 未结束任务的上次状态，用于区分状态变化和进度
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBroadcaster 创建任务事件分发器
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subs:   make(map[chan TaskEvent]int64),
+		status: make(map[int64]string),
+		done:   make(chan struct{}),
+	}
+}
+
+// Close 通知订阅者停止，服务关闭时调用，使SSE连接结束而不阻塞 http.Server.Shutdown
+func (b *Broadcaster) Close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+// Done 返回 Close 后关闭的通道
+func (b *Broadcaster) Done() <-chan struct{} {
+	return b.done
+}
+
+// Subscribe 订阅任务事件，taskID为0时订阅全部任务；调用返回的函数取消订阅
+func (b *Broadcaster) Subscribe(taskID int64) (<-chan TaskEvent, func()) {
+	ch := make(chan TaskEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subs[ch] = taskID
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// TaskUpdated 实现 TaskListener
+func (b *Broadcaster) TaskUpdated(task *models.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := TaskEvent{Type: TaskEventStatus, Task: task}
+	if b.status[task.ID] == task.Status {
+		event.Type = TaskEventProgress
+	}
+	switch task.Status {
+	case models.TaskStatusCompleted, models.TaskStatusFailed, models.TaskStatusCancelled:
+		delete(b.status, task.ID)
+	default:
+		b.status[task.ID] = task.Status
+	}
+
+	for ch, taskID := range b.subs {
+		if taskID != 0 && taskID != task.ID {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			logger.Logger.Warn().Int64("task_id", task.ID).Str("type", event.Type).Msg("task event subscriber too slow, dropping event")
+		}
+	}
+}
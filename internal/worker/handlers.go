@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -13,18 +14,21 @@ import (
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
 	"github.com/hanxuanyu/gitcodestatic/internal/stats"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
+	"github.com/hanxuanyu/gitcodestatic/internal/teams"
 )
 
 // CloneHandler 克隆任务处理器
 type CloneHandler struct {
 	store      storage.Store
 	gitManager git.Manager
+	timeout    time.Duration
 }
 
-func NewCloneHandler(store storage.Store, gitManager git.Manager) *CloneHandler {
+func NewCloneHandler(store storage.Store, gitManager git.Manager, timeout time.Duration) *CloneHandler {
 	return &CloneHandler{
 		store:      store,
 		gitManager: gitManager,
+		timeout:    timeout,
 	}
 }
 
@@ -33,7 +37,7 @@ func (h *CloneHandler) Type() string {
 }
 
 func (h *CloneHandler) Timeout() time.Duration {
-	return 10 * time.Minute
+	return h.timeout
 }
 
 func (h *CloneHandler) Handle(ctx context.Context, task *models.Task) error {
@@ -48,17 +52,20 @@ func (h *CloneHandler) Handle(ctx context.Context, task *models.Task) error {
 	h.store.Repos().Update(ctx, repo)
 
 	// 获取凭据（如果有）
-	var cred *models.Credential
-	if repo.CredentialID != nil {
-		cred, _ = h.store.Credentials().GetByID(ctx, *repo.CredentialID)
+	cred, err := repoCredential(ctx, h.store, repo)
+	if err != nil {
+		markCloneFailed(ctx, h.store, repo, err)
+		return err
 	}
 
-	// 克隆仓库
-	if err := h.gitManager.Clone(ctx, repo.URL, repo.LocalPath, cred); err != nil {
-		errMsg := err.Error()
-		repo.Status = models.RepoStatusFailed
-		repo.ErrorMessage = &errMsg
-		h.store.Repos().Update(ctx, repo)
+	// 克隆仓库，本地仓库原地分析只校验可读
+	if git.IsLocalURL(repo.URL) {
+		err = checkLocalRepo(ctx, h.gitManager, repo)
+	} else {
+		err = cloneRepository(ctx, h.gitManager, repo, cred)
+	}
+	if err != nil {
+		markCloneFailed(ctx, h.store, repo, err)
 		return err
 	}
 
@@ -90,12 +97,14 @@ func (h *CloneHandler) Handle(ctx context.Context, task *models.Task) error {
 type PullHandler struct {
 	store      storage.Store
 	gitManager git.Manager
+	timeout    time.Duration
 }
 
-func NewPullHandler(store storage.Store, gitManager git.Manager) *PullHandler {
+func NewPullHandler(store storage.Store, gitManager git.Manager, timeout time.Duration) *PullHandler {
 	return &PullHandler{
 		store:      store,
 		gitManager: gitManager,
+		timeout:    timeout,
 	}
 }
 
@@ -104,7 +113,7 @@ func (h *PullHandler) Type() string {
 }
 
 func (h *PullHandler) Timeout() time.Duration {
-	return 5 * time.Minute
+	return h.timeout
 }
 
 func (h *PullHandler) Handle(ctx context.Context, task *models.Task) error {
@@ -113,12 +122,17 @@ func (h *PullHandler) Handle(ctx context.Context, task *models.Task) error {
 		return err
 	}
 
-	var cred *models.Credential
-	if repo.CredentialID != nil {
-		cred, _ = h.store.Credentials().GetByID(ctx, *repo.CredentialID)
+	cred, err := repoCredential(ctx, h.store, repo)
+	if err != nil {
+		return err
 	}
 
-	if err := h.gitManager.Pull(ctx, repo.LocalPath, cred); err != nil {
+	// 本地仓库由外部维护（如NFS镜像），不执行拉取，只刷新commit hash
+	if git.IsLocalURL(repo.URL) {
+		if err := checkLocalRepo(ctx, h.gitManager, repo); err != nil {
+			return err
+		}
+	} else if err := h.gitManager.Pull(ctx, repo.LocalPath, cred, git.CloneOptionsFor(repo)); err != nil {
 		return err
 	}
 
@@ -164,13 +178,21 @@ func (h *SwitchHandler) Handle(ctx context.Context, task *models.Task) error {
 		return fmt.Errorf("failed to parse parameters: %w", err)
 	}
 
-	if err := h.gitManager.Checkout(ctx, repo.LocalPath, params.Branch); err != nil {
-		return err
+	// 本地仓库不能修改其HEAD和工作区，只记录默认统计分支
+	var commitHash string
+	if git.IsLocalURL(repo.URL) {
+		if commitHash, err = h.gitManager.ResolveRef(ctx, repo.LocalPath, params.Branch); err != nil {
+			return err
+		}
+	} else {
+		if err := h.gitManager.Checkout(ctx, repo.LocalPath, params.Branch); err != nil {
+			return err
+		}
+		commitHash, _ = h.gitManager.GetHeadCommitHash(ctx, repo.LocalPath)
 	}
 
 	// 更新仓库当前分支
 	repo.CurrentBranch = params.Branch
-	commitHash, _ := h.gitManager.GetHeadCommitHash(ctx, repo.LocalPath)
 	repo.LastCommitHash = &commitHash
 	h.store.Repos().Update(ctx, repo)
 
@@ -182,13 +204,17 @@ type ResetHandler struct {
 	store      storage.Store
 	gitManager git.Manager
 	fileCache  *cache.FileCache
+	cacheDir   string
+	timeout    time.Duration
 }
 
-func NewResetHandler(store storage.Store, gitManager git.Manager, fileCache *cache.FileCache) *ResetHandler {
+func NewResetHandler(store storage.Store, gitManager git.Manager, fileCache *cache.FileCache, cacheDir string, timeout time.Duration) *ResetHandler {
 	return &ResetHandler{
 		store:      store,
 		gitManager: gitManager,
 		fileCache:  fileCache,
+		cacheDir:   cacheDir,
+		timeout:    timeout,
 	}
 }
 
@@ -197,7 +223,7 @@ func (h *ResetHandler) Type() string {
 }
 
 func (h *ResetHandler) Timeout() time.Duration {
-	return 10 * time.Minute
+	return h.timeout
 }
 
 func (h *ResetHandler) Handle(ctx context.Context, task *models.Task) error {
@@ -209,9 +235,14 @@ func (h *ResetHandler) Handle(ctx context.Context, task *models.Task) error {
 	// 1. 删除统计缓存
 	h.fileCache.InvalidateByRepoID(ctx, repo.ID)
 
-	// 2. 删除本地目录
-	if err := os.RemoveAll(repo.LocalPath); err != nil {
-		logger.Logger.Warn().Err(err).Str("path", repo.LocalPath).Msg("failed to remove local path")
+	// 2. 删除本地目录（本地仓库原地分析，绝不删除）
+	// 布局迁移失败的仓库仍指向旧目录，该目录可能属于另一个同名仓库，改为在新布局下重新克隆
+	local := git.IsLocalURL(repo.URL)
+	if !local {
+		repo.LocalPath = git.WorkspacePath(h.cacheDir, repo.URL)
+		if err := os.RemoveAll(repo.LocalPath); err != nil {
+			logger.Logger.Warn().Err(err).Str("path", repo.LocalPath).Msg("failed to remove local path")
+		}
 	}
 
 	// 3. 更新仓库状态为pending
@@ -223,19 +254,22 @@ func (h *ResetHandler) Handle(ctx context.Context, task *models.Task) error {
 	h.store.Repos().Update(ctx, repo)
 
 	// 4. 重新克隆
-	var cred *models.Credential
-	if repo.CredentialID != nil {
-		cred, _ = h.store.Credentials().GetByID(ctx, *repo.CredentialID)
+	cred, err := repoCredential(ctx, h.store, repo)
+	if err != nil {
+		markCloneFailed(ctx, h.store, repo, err)
+		return err
 	}
 
 	repo.Status = models.RepoStatusCloning
 	h.store.Repos().Update(ctx, repo)
 
-	if err := h.gitManager.Clone(ctx, repo.URL, repo.LocalPath, cred); err != nil {
-		errMsg := err.Error()
-		repo.Status = models.RepoStatusFailed
-		repo.ErrorMessage = &errMsg
-		h.store.Repos().Update(ctx, repo)
+	if local {
+		err = checkLocalRepo(ctx, h.gitManager, repo)
+	} else {
+		err = cloneRepository(ctx, h.gitManager, repo, cred)
+	}
+	if err != nil {
+		markCloneFailed(ctx, h.store, repo, err)
 		return err
 	}
 
@@ -253,20 +287,78 @@ func (h *ResetHandler) Handle(ctx context.Context, task *models.Task) error {
 	return nil
 }
 
+// repoCredential 获取仓库绑定的凭据，凭据绑定的主机与仓库不一致时拒绝使用
+func repoCredential(ctx context.Context, store storage.Store, repo *models.Repository) (*models.Credential, error) {
+	if repo.CredentialID == nil || git.IsLocalURL(repo.URL) {
+		return nil, nil
+	}
+
+	cred, _ := store.Credentials().GetByID(ctx, *repo.CredentialID)
+	if cred == nil {
+		return nil, nil
+	}
+	if err := git.CheckCredentialHost(cred, repo.URL); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// cloneRepository 克隆仓库，失败（包括任务被取消）时删除本次写入的不完整目录，避免之后的克隆因目录已存在而失败
+func cloneRepository(ctx context.Context, gitManager git.Manager, repo *models.Repository, cred *models.Credential) error {
+	_, statErr := os.Stat(repo.LocalPath)
+
+	err := gitManager.Clone(ctx, repo.URL, repo.LocalPath, cred, git.CloneOptionsFor(repo))
+	if err != nil && os.IsNotExist(statErr) {
+		if rmErr := os.RemoveAll(repo.LocalPath); rmErr != nil {
+			logger.Logger.Warn().Err(rmErr).Str("path", repo.LocalPath).Msg("failed to remove partial clone")
+		}
+	}
+	return err
+}
+
+// markCloneFailed 将仓库标记为克隆失败
+//
+// 任务可能已被取消或超时，使用不随任务取消的context写入，避免仓库停留在cloning状态。
+func markCloneFailed(ctx context.Context, store storage.Store, repo *models.Repository, err error) {
+	errMsg := err.Error()
+	if errors.Is(context.Cause(ctx), ErrTaskCancelled) {
+		errMsg = "clone cancelled"
+	}
+
+	repo.Status = models.RepoStatusFailed
+	repo.ErrorMessage = &errMsg
+	if err := store.Repos().Update(context.WithoutCancel(ctx), repo); err != nil {
+		logger.Logger.Error().Err(err).Int64("repo_id", repo.ID).Msg("failed to mark repository clone failed")
+	}
+}
+
+// checkLocalRepo 校验本地仓库路径存在且是可读取的git仓库
+func checkLocalRepo(ctx context.Context, gitManager git.Manager, repo *models.Repository) error {
+	if _, err := os.Stat(repo.LocalPath); err != nil {
+		return fmt.Errorf("local repository not accessible: %w", err)
+	}
+	if _, err := gitManager.GetHeadCommitHash(ctx, repo.LocalPath); err != nil {
+		return fmt.Errorf("not a readable git repository: %s: %w", repo.LocalPath, err)
+	}
+	return nil
+}
+
 // StatsHandler 统计任务处理器
 type StatsHandler struct {
 	store      storage.Store
 	calculator *stats.Calculator
 	fileCache  *cache.FileCache
 	gitManager git.Manager
+	teams      *teams.Registry
 }
 
-func NewStatsHandler(store storage.Store, calculator *stats.Calculator, fileCache *cache.FileCache, gitManager git.Manager) *StatsHandler {
+func NewStatsHandler(store storage.Store, calculator *stats.Calculator, fileCache *cache.FileCache, gitManager git.Manager, teamRegistry *teams.Registry) *StatsHandler {
 	return &StatsHandler{
 		store:      store,
 		calculator: calculator,
 		fileCache:  fileCache,
 		gitManager: gitManager,
+		teams:      teamRegistry,
 	}
 }
 
@@ -289,14 +381,20 @@ func (h *StatsHandler) Handle(ctx context.Context, task *models.Task) error {
 		return fmt.Errorf("failed to parse parameters: %w", err)
 	}
 
-	// 获取当前HEAD commit hash
-	commitHash, err := h.gitManager.GetHeadCommitHash(ctx, repo.LocalPath)
+	// 将分支解析为commit hash，统计固定在该提交上，期间的pull不会影响结果
+	commitHash, err := h.gitManager.ResolveRef(ctx, repo.LocalPath, params.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve branch %s: %w", params.Branch, err)
+	}
+
+	// 获取团队映射，映射版本参与缓存键
+	mapper, err := h.teams.Mapper(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get HEAD commit hash: %w", err)
+		return fmt.Errorf("failed to load team mapping: %w", err)
 	}
 
 	// 检查缓存
-	cacheKey := cache.GenerateCacheKey(repo.ID, params.Branch, params.Constraint, commitHash)
+	cacheKey := cache.GenerateCacheKey(repo.ID, params.Branch, params.Constraint, commitHash, mapper.Version())
 	cached, _ := h.fileCache.Get(ctx, cacheKey)
 	if cached != nil {
 		// 缓存命中，直接返回
@@ -314,14 +412,23 @@ func (h *StatsHandler) Handle(ctx context.Context, task *models.Task) error {
 		return nil
 	}
 
+	// 部分克隆读取numstat时会按需拉取对象，需要仓库凭据
+	cred, err := repoCredential(ctx, h.store, repo)
+	if err != nil {
+		return err
+	}
+
 	// 执行统计
-	statistics, err := h.calculator.Calculate(ctx, repo.LocalPath, params.Branch, params.Constraint)
+	statistics, err := h.calculator.Calculate(git.WithCredential(ctx, cred), repo.LocalPath, commitHash, params.Constraint)
 	if err != nil {
 		return fmt.Errorf("failed to calculate statistics: %w", err)
 	}
 
+	// 按团队汇总
+	statistics.ByTeam = mapper.Aggregate(statistics.ByContributor)
+
 	// 保存到缓存
-	if err := h.fileCache.Set(ctx, repo.ID, params.Branch, params.Constraint, commitHash, statistics); err != nil {
+	if err := h.fileCache.Set(ctx, repo.ID, params.Branch, params.Constraint, commitHash, mapper.Version(), statistics); err != nil {
 		logger.Logger.Warn().Err(err).Msg("failed to save statistics to cache")
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
 )
@@ -13,20 +14,22 @@ type Pool struct {
 	queue    *Queue
 	workers  []*Worker
 	handlers map[string]TaskHandler
+	retry    map[string]config.RetryConfig
 	store    storage.Store
 	ctx      context.Context
 	cancel   context.CancelFunc
 	wg       sync.WaitGroup
 }
 
-// NewPool 创建Worker池
-func NewPool(workerCount int, queue *Queue, store storage.Store, handlers map[string]TaskHandler) *Pool {
+// NewPool 创建Worker池，retry为按任务类型的重试策略
+func NewPool(workerCount int, queue *Queue, store storage.Store, handlers map[string]TaskHandler, retry map[string]config.RetryConfig) *Pool {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	pool := &Pool{
 		queue:    queue,
 		workers:  make([]*Worker, 0, workerCount),
 		handlers: handlers,
+		retry:    retry,
 		store:    store,
 		ctx:      ctx,
 		cancel:   cancel,
@@ -34,7 +37,7 @@ func NewPool(workerCount int, queue *Queue, store storage.Store, handlers map[st
 
 	// 创建workers
 	for i := 0; i < workerCount; i++ {
-		worker := NewWorker(i+1, queue, store, handlers)
+		worker := NewWorker(i+1, queue, store, handlers, retry)
 		pool.workers = append(pool.workers, worker)
 	}
 
@@ -59,6 +62,7 @@ func (p *Pool) Stop() {
 	for _, worker := range p.workers {
 		worker.Stop()
 	}
+	p.wg.Wait()
 
 	p.queue.Close()
 
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/progress"
+)
+
+// progressInterval 同一阶段内两次写入进度的最小间隔，git每秒会输出数十行进度
+const progressInterval = time.Second
+
+// progressRecorder 将任务上报的进度节流后写入存储并通知监听器
+type progressRecorder struct {
+	ctx    context.Context
+	queue  *Queue
+	taskID int64
+
+	mu    sync.Mutex
+	phase string
+	last  time.Time
+}
+
+// newProgressRecorder 创建任务进度记录器，ctx用于写入存储，不随任务超时取消
+func newProgressRecorder(ctx context.Context, queue *Queue, taskID int64) *progressRecorder {
+	return &progressRecorder{
+		ctx:    ctx,
+		queue:  queue,
+		taskID: taskID,
+	}
+}
+
+// Record 实现 progress.Func，阶段变化时立即写入，同一阶段内按 progressInterval 节流
+func (r *progressRecorder) Record(p progress.Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if p.Phase == r.phase && now.Sub(r.last) < progressInterval {
+		return
+	}
+	r.phase = p.Phase
+	r.last = now
+
+	taskProgress := &models.TaskProgress{
+		Phase:     p.Phase,
+		Percent:   p.Percent,
+		Message:   p.Message,
+		UpdatedAt: now,
+	}
+	if err := r.queue.store.Tasks().UpdateProgress(r.ctx, r.taskID, taskProgress); err != nil {
+		logger.Logger.Warn().Err(err).Int64("task_id", r.taskID).Msg("failed to update task progress")
+		return
+	}
+	r.queue.notify(r.ctx, r.taskID)
+}
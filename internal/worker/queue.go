@@ -2,26 +2,57 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
 )
 
+var (
+	// ErrTaskCancelled 任务被取消，作为运行中任务context的取消原因
+	ErrTaskCancelled = errors.New("task cancelled")
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskFinished 任务已结束，不能取消
+	ErrTaskFinished = errors.New("task already finished")
+	// ErrQueueClosed 队列已关闭（服务正在停止），不再接受任务
+	ErrQueueClosed = errors.New("task queue closed")
+)
+
+// enqueueRetryInterval 队列已满时重新尝试放入的间隔
+const enqueueRetryInterval = 100 * time.Millisecond
+
+// TaskListener 任务状态变化监听器，在同步调用中执行，实现方不应阻塞
+type TaskListener interface {
+	TaskUpdated(task *models.Task)
+}
+
 // Queue 任务队列
 type Queue struct {
-	taskChan chan *models.Task
-	store    storage.Store
-	mu       sync.RWMutex
+	taskChan  chan *models.Task
+	store     storage.Store
+	mu        sync.RWMutex
+	listeners []TaskListener
+
+	runMu     sync.Mutex
+	running   map[int64]context.CancelCauseFunc // 运行中任务的取消函数
+	cancelled map[int64]bool                    // 仍在通道中但已取消的任务，出队时跳过
+	retries   map[int64]*time.Timer             // 等待重试的任务
+	closed    bool
 }
 
 // NewQueue 创建任务队列
 func NewQueue(bufferSize int, store storage.Store) *Queue {
 	return &Queue{
-		taskChan: make(chan *models.Task, bufferSize),
-		store:    store,
+		taskChan:  make(chan *models.Task, bufferSize),
+		store:     store,
+		running:   make(map[int64]context.CancelCauseFunc),
+		cancelled: make(map[int64]bool),
+		retries:   make(map[int64]*time.Timer),
 	}
 }
 
@@ -47,26 +78,68 @@ func (q *Queue) Enqueue(ctx context.Context, task *models.Task) error {
 		return nil
 	}
 
+	if q.isClosed() {
+		return ErrQueueClosed
+	}
+
 	// 创建新任务
 	task.Status = models.TaskStatusPending
 	if err := q.store.Tasks().Create(ctx, task); err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
 
-	// 加入队列
+	q.publish(task)
+
+	// 加入队列，队列已满时等待worker取走任务
+	ticker := time.NewTicker(enqueueRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := q.trySend(task)
+		if err != nil {
+			return err
+		}
+		if sent {
+			logger.Logger.Info().
+				Int64("task_id", task.ID).
+				Int64("repo_id", task.RepoID).
+				Str("task_type", task.TaskType).
+				Msg("task enqueued")
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// trySend 在队列未关闭时非阻塞地放入任务，与 Close 互斥，避免向已关闭的通道发送
+func (q *Queue) trySend(task *models.Task) (bool, error) {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	if q.closed {
+		return false, ErrQueueClosed
+	}
+
 	select {
 	case q.taskChan <- task:
-		logger.Logger.Info().
-			Int64("task_id", task.ID).
-			Int64("repo_id", task.RepoID).
-			Str("task_type", task.TaskType).
-			Msg("task enqueued")
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+		return true, nil
+	default:
+		return false, nil
 	}
 }
 
+// isClosed 队列是否已关闭
+func (q *Queue) isClosed() bool {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+	return q.closed
+}
+
 // Dequeue 从队列取出任务
 func (q *Queue) Dequeue(ctx context.Context) (*models.Task, error) {
 	select {
@@ -77,12 +150,192 @@ func (q *Queue) Dequeue(ctx context.Context) (*models.Task, error) {
 	}
 }
 
+// Wait 等待任务结束（完成、失败或取消），返回任务最终状态
+func (q *Queue) Wait(ctx context.Context, taskID int64) (*models.Task, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		task, err := q.store.Tasks().GetByID(ctx, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		if task == nil {
+			return nil, fmt.Errorf("task %d not found", taskID)
+		}
+
+		switch task.Status {
+		case models.TaskStatusCompleted, models.TaskStatusFailed, models.TaskStatusCancelled:
+			return task, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Cancel 取消任务
+//
+// 待处理的任务直接标记为已取消，出队时跳过；运行中的任务取消其context，git进程随之终止，
+// 由worker在处理器返回后标记为已取消。返回取消后的任务，运行中的任务此时状态仍为running。
+func (q *Queue) Cancel(ctx context.Context, taskID int64) (*models.Task, error) {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	if cancel, ok := q.running[taskID]; ok {
+		cancel(ErrTaskCancelled)
+		logger.Logger.Info().Int64("task_id", taskID).Msg("cancelling running task")
+		return q.store.Tasks().GetByID(ctx, taskID)
+	}
+
+	task, err := q.store.Tasks().GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	switch task.Status {
+	case models.TaskStatusPending:
+		if err := q.store.Tasks().Cancel(ctx, taskID); err != nil {
+			return nil, fmt.Errorf("failed to cancel task: %w", err)
+		}
+		if timer, ok := q.retries[taskID]; ok && timer.Stop() {
+			delete(q.retries, taskID)
+		} else {
+			q.cancelled[taskID] = true
+		}
+	case models.TaskStatusRunning:
+		// 没有worker在执行，是上次进程退出时遗留的任务
+		if err := q.store.Tasks().UpdateStatus(ctx, taskID, models.TaskStatusCancelled, nil); err != nil {
+			return nil, fmt.Errorf("failed to cancel task: %w", err)
+		}
+	default:
+		return nil, ErrTaskFinished
+	}
+
+	logger.Logger.Info().Int64("task_id", taskID).Str("status", task.Status).Msg("task cancelled")
+
+	task, err = q.store.Tasks().GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task != nil {
+		q.publish(task)
+	}
+	return task, nil
+}
+
+// begin 登记开始执行的任务，返回可被 Cancel 取消的context；任务已取消时返回false
+func (q *Queue) begin(ctx context.Context, taskID int64) (context.Context, bool) {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	if q.cancelled[taskID] {
+		delete(q.cancelled, taskID)
+		return nil, false
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	q.running[taskID] = cancel
+	return runCtx, true
+}
+
+// finish 任务执行结束，释放其context
+func (q *Queue) finish(taskID int64) {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	if cancel, ok := q.running[taskID]; ok {
+		cancel(nil)
+		delete(q.running, taskID)
+	}
+}
+
+// retryLater 等待delay后将任务重新放入队列，队列已满时每秒重试一次
+func (q *Queue) retryLater(task *models.Task, delay time.Duration) {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.retries[task.ID] = time.AfterFunc(delay, func() {
+		q.runMu.Lock()
+		defer q.runMu.Unlock()
+
+		delete(q.retries, task.ID)
+		if q.closed {
+			return
+		}
+		select {
+		case q.taskChan <- task:
+			logger.Logger.Info().Int64("task_id", task.ID).Int("retry_count", task.RetryCount).Msg("task requeued for retry")
+		default:
+			go q.retryLater(task, time.Second)
+		}
+	})
+}
+
+// AddListener 注册任务状态变化监听器
+func (q *Queue) AddListener(listener TaskListener) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.listeners = append(q.listeners, listener)
+}
+
+// notify 读取任务最新状态并通知监听器
+func (q *Queue) notify(ctx context.Context, taskID int64) {
+	if !q.hasListeners() {
+		return
+	}
+
+	task, err := q.store.Tasks().GetByID(ctx, taskID)
+	if err != nil || task == nil {
+		logger.Logger.Warn().Err(err).Int64("task_id", taskID).Msg("failed to load task for listeners")
+		return
+	}
+
+	q.publish(task)
+}
+
+// publish 将任务快照交给监听器，监听器之间及与worker之间不共享同一对象
+func (q *Queue) publish(task *models.Task) {
+	q.mu.RLock()
+	listeners := q.listeners
+	q.mu.RUnlock()
+
+	for _, listener := range listeners {
+		snapshot := *task
+		listener.TaskUpdated(&snapshot)
+	}
+}
+
+// hasListeners 是否注册了监听器
+func (q *Queue) hasListeners() bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return len(q.listeners) > 0
+}
+
 // Size 返回队列长度
 func (q *Queue) Size() int {
 	return len(q.taskChan)
 }
 
-// Close 关闭队列
+// Close 关闭队列，等待重试的任务保持pending状态，之后的 Enqueue 返回 ErrQueueClosed
 func (q *Queue) Close() {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	q.closed = true
+	for taskID, timer := range q.retries {
+		timer.Stop()
+		delete(q.retries, taskID)
+	}
 	close(q.taskChan)
 }
@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// errInterrupted 上次运行中断的任务记录的错误
+const errInterrupted = "task interrupted by server restart"
+
+// Recover 恢复上次运行遗留的任务，需在worker启动后调用
+//
+// 队列只在内存中，进程退出后pending任务不会再被执行，running任务也不会结束，
+// 并且会使 FindExisting 去重一直返回这些任务。启动时：
+//   - pending任务按创建顺序重新入队
+//   - running任务记录一次中断的执行，按重试策略重新入队或标记为失败
+//   - cloning状态的仓库删除不完整的目录，有克隆任务重新入队时置为pending，否则标记为失败
+func (p *Pool) Recover(ctx context.Context) error {
+	pending, err := p.listTasks(ctx, models.TaskStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to list pending tasks: %w", err)
+	}
+	running, err := p.listTasks(ctx, models.TaskStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to list running tasks: %w", err)
+	}
+
+	requeue := pending
+	failed := 0
+	for _, task := range running {
+		startedAt := task.UpdatedAt
+		if task.StartedAt != nil {
+			startedAt = *task.StartedAt
+		}
+
+		retry := addAttempt(task, p.retry, errInterrupted, true, startedAt)
+		if err := p.store.Tasks().Update(ctx, task); err != nil {
+			logger.Logger.Error().Err(err).Int64("task_id", task.ID).Msg("failed to record interrupted task")
+			continue
+		}
+		if retry {
+			p.queue.notify(ctx, task.ID)
+			requeue = append(requeue, task)
+			continue
+		}
+
+		errMsg := errInterrupted
+		if err := p.store.Tasks().UpdateStatus(ctx, task.ID, models.TaskStatusFailed, &errMsg); err != nil {
+			logger.Logger.Error().Err(err).Int64("task_id", task.ID).Msg("failed to mark interrupted task failed")
+			continue
+		}
+		p.queue.notify(ctx, task.ID)
+		failed++
+	}
+
+	// 任务ID随创建递增，按ID排序即按创建顺序
+	sort.Slice(requeue, func(i, j int) bool { return requeue[i].ID < requeue[j].ID })
+
+	resetRepos, err := p.resetCloningRepos(ctx, requeue)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info().
+		Int("requeued", len(requeue)).
+		Int("interrupted", len(running)).
+		Int("failed", failed).
+		Int("repos_reset", resetRepos).
+		Msg("task queue recovered")
+
+	if len(requeue) == 0 {
+		return nil
+	}
+
+	// 待恢复的任务可能超过队列缓冲，在后台写入，避免阻塞启动
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for _, task := range requeue {
+			select {
+			case p.queue.taskChan <- task:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// resetCloningRepos 处理克隆中断的仓库，返回处理的仓库数
+//
+// 中断的克隆可能留下不完整的目录，之后的克隆会因目录已存在而失败，因此先删除。
+func (p *Pool) resetCloningRepos(ctx context.Context, requeue []*models.Task) (int, error) {
+	repos, err := p.listRepos(ctx, models.RepoStatusCloning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cloning repositories: %w", err)
+	}
+
+	recloning := make(map[int64]bool)
+	for _, task := range requeue {
+		if task.TaskType == models.TaskTypeClone || task.TaskType == models.TaskTypeReset {
+			recloning[task.RepoID] = true
+		}
+	}
+
+	for _, repo := range repos {
+		if !git.IsLocalURL(repo.URL) {
+			if err := os.RemoveAll(repo.LocalPath); err != nil {
+				logger.Logger.Warn().Err(err).Str("path", repo.LocalPath).Msg("failed to remove partial clone")
+			}
+		}
+
+		if recloning[repo.ID] {
+			repo.Status = models.RepoStatusPending
+			repo.ErrorMessage = nil
+		} else {
+			errMsg := "clone interrupted by server restart, reset the repository to clone again"
+			repo.Status = models.RepoStatusFailed
+			repo.ErrorMessage = &errMsg
+		}
+		if err := p.store.Repos().Update(ctx, repo); err != nil {
+			logger.Logger.Error().Err(err).Int64("repo_id", repo.ID).Msg("failed to reset cloning repository")
+			continue
+		}
+
+		logger.Logger.Info().
+			Int64("repo_id", repo.ID).
+			Str("status", repo.Status).
+			Msg("repository clone interrupted, status reset")
+	}
+
+	return len(repos), nil
+}
+
+// listTasks 分页读取指定状态的全部任务
+func (p *Pool) listTasks(ctx context.Context, status string) ([]*models.Task, error) {
+	const pageSize = 100
+
+	all := make([]*models.Task, 0)
+	for page := 1; ; page++ {
+		tasks, total, err := p.store.Tasks().List(ctx, 0, status, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, tasks...)
+		if len(tasks) < pageSize || len(all) >= total {
+			break
+		}
+	}
+
+	return all, nil
+}
+
+// listRepos 分页读取指定状态的全部仓库
+func (p *Pool) listRepos(ctx context.Context, status string) ([]*models.Repository, error) {
+	const pageSize = 100
+
+	all := make([]*models.Repository, 0)
+	for page := 1; ; page++ {
+		repos, total, err := p.store.Repos().List(ctx, status, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if len(repos) < pageSize || len(all) >= total {
+			break
+		}
+	}
+
+	return all, nil
+}
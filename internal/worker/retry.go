@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
+	"github.com/hanxuanyu/gitcodestatic/internal/git"
+	"github.com/hanxuanyu/gitcodestatic/internal/logger"
+	"github.com/hanxuanyu/gitcodestatic/internal/models"
+)
+
+// recordFailure 在任务上记录失败的执行，临时失败且未达到最大次数时安排重试，返回是否已安排重试
+//
+// timedOut 表示任务超时，超时视为临时失败。
+func (w *Worker) recordFailure(ctx context.Context, task *models.Task, startedAt time.Time, err error, timedOut bool) bool {
+	current, getErr := w.store.Tasks().GetByID(ctx, task.ID)
+	if getErr != nil || current == nil {
+		logger.Logger.Error().Err(getErr).Int64("task_id", task.ID).Msg("failed to load task to record attempt")
+		return false
+	}
+
+	errMsg := err.Error()
+	retry := addAttempt(current, w.retry, errMsg, timedOut || git.IsRetryable(err), startedAt)
+
+	if err := w.store.Tasks().Update(ctx, current); err != nil {
+		logger.Logger.Error().Err(err).Int64("task_id", task.ID).Msg("failed to record task attempt")
+		return false
+	}
+	if !retry {
+		return false
+	}
+
+	delay := retryBackoff(w.retry[current.TaskType], current.RetryCount)
+	logger.Logger.Warn().
+		Int64("task_id", task.ID).
+		Str("task_type", current.TaskType).
+		Int("retry_count", current.RetryCount).
+		Dur("delay", delay).
+		Msg("task failed with transient error, retry scheduled")
+
+	w.queue.retryLater(current, delay)
+	w.queue.notify(ctx, task.ID)
+	return true
+}
+
+// addAttempt 在任务上记录一次失败的执行；临时失败且未达到该任务类型的最大次数时，
+// 将任务置为pending并增加重试次数，返回是否重试
+func addAttempt(task *models.Task, retry map[string]config.RetryConfig, errMsg string, retryable bool, startedAt time.Time) bool {
+	task.Attempts = append(task.Attempts, models.TaskAttempt{
+		Attempt:    task.RetryCount + 1,
+		Error:      errMsg,
+		Retryable:  retryable,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	})
+	task.ErrorMessage = &errMsg
+
+	policy, ok := retry[task.TaskType]
+	if !ok || !retryable || task.RetryCount+1 >= policy.MaxAttempts {
+		return false
+	}
+	task.RetryCount++
+	task.Status = models.TaskStatusPending
+	return true
+}
+
+// retryBackoff 第retry次重试前的等待时间：initial*2^(retry-1)，不超过上限，再按比例随机浮动
+func retryBackoff(policy config.RetryConfig, retry int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < retry && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if policy.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * policy.Jitter * float64(delay))
+	}
+	return delay
+}
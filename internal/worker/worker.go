@@ -2,12 +2,15 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/hanxuanyu/gitcodestatic/internal/config"
 	"github.com/hanxuanyu/gitcodestatic/internal/logger"
 	"github.com/hanxuanyu/gitcodestatic/internal/models"
+	"github.com/hanxuanyu/gitcodestatic/internal/progress"
 	"github.com/hanxuanyu/gitcodestatic/internal/storage"
 )
 
@@ -23,17 +26,19 @@ type Worker struct {
 	id       int
 	queue    *Queue
 	handlers map[string]TaskHandler
+	retry    map[string]config.RetryConfig
 	store    storage.Store
 	stopCh   chan struct{}
 	wg       *sync.WaitGroup
 }
 
-// NewWorker 创建工作器
-func NewWorker(id int, queue *Queue, store storage.Store, handlers map[string]TaskHandler) *Worker {
+// NewWorker 创建工作器，retry为按任务类型的重试策略
+func NewWorker(id int, queue *Queue, store storage.Store, handlers map[string]TaskHandler, retry map[string]config.RetryConfig) *Worker {
 	return &Worker{
 		id:       id,
 		queue:    queue,
 		handlers: handlers,
+		retry:    retry,
 		store:    store,
 		stopCh:   make(chan struct{}),
 		wg:       &sync.WaitGroup{},
@@ -90,6 +95,14 @@ func (w *Worker) run(ctx context.Context) {
 
 // handleTask 处理任务
 func (w *Worker) handleTask(ctx context.Context, task *models.Task) {
+	// 登记为运行中，取消请求通过runCtx传递到处理器
+	runCtx, ok := w.queue.begin(ctx, task.ID)
+	if !ok {
+		logger.Logger.Info().Int64("task_id", task.ID).Msg("task cancelled before start, skipping")
+		return
+	}
+	defer w.queue.finish(task.ID)
+
 	startTime := time.Now()
 
 	logger.Logger.Info().
@@ -104,6 +117,7 @@ func (w *Worker) handleTask(ctx context.Context, task *models.Task) {
 		logger.Logger.Error().Err(err).Int64("task_id", task.ID).Msg("failed to update task status to running")
 		return
 	}
+	w.queue.notify(ctx, task.ID)
 
 	// 查找处理器
 	handler, ok := w.handlers[task.TaskType]
@@ -111,19 +125,34 @@ func (w *Worker) handleTask(ctx context.Context, task *models.Task) {
 		errMsg := fmt.Sprintf("no handler found for task type: %s", task.TaskType)
 		logger.Logger.Error().Int64("task_id", task.ID).Str("task_type", task.TaskType).Msg(errMsg)
 		w.store.Tasks().UpdateStatus(ctx, task.ID, models.TaskStatusFailed, &errMsg)
+		w.queue.notify(ctx, task.ID)
 		return
 	}
 
 	// 创建带超时的上下文
 	timeout := handler.Timeout()
-	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	taskCtx, cancel := context.WithTimeout(runCtx, timeout)
 	defer cancel()
+	taskCtx = progress.WithReporter(taskCtx, newProgressRecorder(ctx, w.queue, task.ID).Record)
 
 	// 执行任务
 	err := handler.Handle(taskCtx, task)
 
 	duration := time.Since(startTime)
 
+	if err != nil && errors.Is(context.Cause(runCtx), ErrTaskCancelled) {
+		logger.Logger.Info().
+			Int("worker_id", w.id).
+			Int64("task_id", task.ID).
+			Str("task_type", task.TaskType).
+			Int64("duration_ms", duration.Milliseconds()).
+			Msg("task cancelled")
+
+		w.store.Tasks().UpdateStatus(ctx, task.ID, models.TaskStatusCancelled, nil)
+		w.queue.notify(ctx, task.ID)
+		return
+	}
+
 	if err != nil {
 		errMsg := err.Error()
 		logger.Logger.Error().
@@ -134,7 +163,11 @@ func (w *Worker) handleTask(ctx context.Context, task *models.Task) {
 			Int64("duration_ms", duration.Milliseconds()).
 			Msg("task failed")
 
+		if w.recordFailure(ctx, task, startTime, err, errors.Is(taskCtx.Err(), context.DeadlineExceeded)) {
+			return
+		}
 		w.store.Tasks().UpdateStatus(ctx, task.ID, models.TaskStatusFailed, &errMsg)
+		w.queue.notify(ctx, task.ID)
 		return
 	}
 
@@ -146,5 +179,10 @@ func (w *Worker) handleTask(ctx context.Context, task *models.Task) {
 		Int64("duration_ms", duration.Milliseconds()).
 		Msg("task completed")
 
+	// 完成后清除进度，失败的任务保留最后的进度便于定位
+	if err := w.store.Tasks().UpdateProgress(ctx, task.ID, nil); err != nil {
+		logger.Logger.Warn().Err(err).Int64("task_id", task.ID).Msg("failed to clear task progress")
+	}
 	w.store.Tasks().UpdateStatus(ctx, task.ID, models.TaskStatusCompleted, nil)
+	w.queue.notify(ctx, task.ID)
 }
@@ -40,6 +40,7 @@ createApp({
             selectedStatsResult: null,
             tasks: [],
             tasksLoading: false,
+            taskStream: null,
             caches: [],
             cachesLoading: false
         };
@@ -48,12 +49,19 @@ createApp({
         this.loadRepos();
         this.loadCaches();
     },
+    beforeUnmount() {
+        this.closeTaskStream();
+    },
     watch: {
         activeTab(newTab) {
             if (newTab === 'tasks') {
                 this.loadTasks();
-            } else if (newTab === 'caches') {
-                this.loadCaches();
+                this.openTaskStream();
+            } else {
+                this.closeTaskStream();
+                if (newTab === 'caches') {
+                    this.loadCaches();
+                }
             }
         }
     },
@@ -323,6 +331,29 @@ createApp({
                 this.tasksLoading = false;
             }
         },
+        openTaskStream() {
+            if (this.taskStream || !window.EventSource) return;
+            // 通过SSE增量更新任务列表，断线后浏览器自动重连
+            const source = new EventSource(`${API_BASE}/tasks/stream`);
+            const onEvent = (e) => this.upsertTask(JSON.parse(e.data));
+            source.addEventListener('status', onEvent);
+            source.addEventListener('progress', onEvent);
+            this.taskStream = source;
+        },
+        closeTaskStream() {
+            if (this.taskStream) {
+                this.taskStream.close();
+                this.taskStream = null;
+            }
+        },
+        upsertTask(task) {
+            const index = this.tasks.findIndex(t => t.id === task.id);
+            if (index >= 0) {
+                this.tasks.splice(index, 1, { ...this.tasks[index], ...task });
+            } else {
+                this.tasks.unshift(task);
+            }
+        },
         async loadCaches() {
             this.cachesLoading = true;
             try {
@@ -401,6 +432,27 @@ createApp({
                 }
             }
         },
+        async cancelTask(task) {
+            try {
+                await ElMessageBox.confirm(`确定要取消任务 #${task.id} 吗？运行中的git操作将被终止。`, '提示', {
+                    confirmButtonText: '确定',
+                    cancelButtonText: '取消',
+                    type: 'warning'
+                });
+
+                const response = await axios.post(`${API_BASE}/tasks/${task.id}/cancel`);
+                if (response.data.code === 0) {
+                    ElMessage.success('已提交取消');
+                    this.upsertTask(response.data.data);
+                } else {
+                    ElMessage.error(response.data.message || '取消任务失败');
+                }
+            } catch (error) {
+                if (error !== 'cancel') {
+                    ElMessage.error('取消任务失败: ' + (error.response?.data?.message || error.message));
+                }
+            }
+        },
         async viewStatsCache(cache) {
             this.statsLoading = true;
             try {